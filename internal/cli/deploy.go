@@ -8,9 +8,11 @@ import (
 	"github.com/tetratom/cftool/internal"
 	"github.com/tetratom/cftool/pkg/cftool"
 	manifest2 "github.com/tetratom/cftool/pkg/manifest"
+	"github.com/tetratom/cftool/pkg/policy"
 	"github.com/tetratom/cftool/pkg/pprint"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Deploy(c context.Context, globalOpts GlobalOptions, deployOpts DeployOptions) (err error) {
@@ -19,36 +21,26 @@ func Deploy(c context.Context, globalOpts GlobalOptions, deployOpts DeployOption
 		return err
 	}
 
-	manifestPath := deployOpts.ManifestFile
-	if manifestPath == "" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return err
-		}
-
-		manifestPath, err = findManifest(cwd)
-		if err != nil {
-			return err
-		}
+	manifest, manifestPath, err := loadManifest(deployOpts.ManifestFile)
+	if err != nil {
+		return err
 	}
 
 	pprint.Field(color.Output, "Manifest", manifestPath)
 
-	manifest, err := manifest2.ReadFromFile(manifestPath)
+	pol, err := loadPolicy(manifest)
 	if err != nil {
-		return
+		return err
 	}
 
-	if err = os.Chdir(filepath.Dir(manifestPath)); err != nil {
-		return
+	deployments, err := manifest.FindDeployments(deployOpts.Tenants, deployOpts.Stacks)
+	if err != nil {
+		return err
 	}
 
-	var deployments []*cftool.Deployment
-
-	if deployment, ok, err := manifest.FindDeployment(deployOpts.Tenant, deployOpts.Stack); err != nil {
+	deployments, err = narrowRegion(deployments, globalOpts.AWS.Region)
+	if err != nil {
 		return err
-	} else if ok {
-		deployments = append(deployments, deployment)
 	}
 
 	for i, deployment := range deployments {
@@ -56,6 +48,9 @@ func Deploy(c context.Context, globalOpts GlobalOptions, deployOpts DeployOption
 			fmt.Fprint(color.Output, "\n")
 		}
 
+		pprint.Field(color.Output, "Tenant", deployment.TenantLabel)
+		pprint.Field(color.Output, "Stack", deployment.StackLabel)
+
 		api, err := globalOpts.AWS.CloudFormationClient(deployment.Region)
 		if err != nil {
 			return err
@@ -63,6 +58,9 @@ func Deploy(c context.Context, globalOpts GlobalOptions, deployOpts DeployOption
 
 		deployer := internal.NewDeployer(api, deployment)
 		deployer.ShowDiff = deployOpts.ShowDiff
+		deployer.Events = globalOpts.events
+		deployer.Policy = pol
+		deployer.Prompter = globalOpts.prompter
 
 		id, err := deployer.Whoami(color.Output, stsapi, getRegion(api))
 		if err != nil {
@@ -86,6 +84,76 @@ func Deploy(c context.Context, globalOpts GlobalOptions, deployOpts DeployOption
 	return nil
 }
 
+// narrowRegion selects the deployments to the given region, if any. Those
+// without an explicit region deploy to the region given on the command line,
+// and are always selected.
+func narrowRegion(deployments []*cftool.Deployment, region string) ([]*cftool.Deployment, error) {
+	if region == "" {
+		return deployments, nil
+	}
+
+	var result []*cftool.Deployment
+	var regions []string
+	for _, d := range deployments {
+		if d.Region == "" || d.Region == region {
+			result = append(result, d)
+		} else {
+			regions = append(regions, d.Region)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, errors.Errorf(
+			"no deployments in region %s; selected deployments are in: %s",
+			region, strings.Join(regions, ", "))
+	}
+
+	return result, nil
+}
+
+// loadManifest reads the manifest at path, or finds one in an enclosing
+// directory if path is empty. The working directory is changed to that of
+// the manifest, as paths within it are relative to its location.
+func loadManifest(path string) (*manifest2.Manifest, string, error) {
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, "", err
+		}
+
+		path, err = findManifest(cwd)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	manifest, err := manifest2.ReadFromFile(path)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "read manifest %s", path)
+	}
+
+	if err := os.Chdir(filepath.Dir(path)); err != nil {
+		return nil, "", err
+	}
+
+	return manifest, path, nil
+}
+
+// loadPolicy reads the policy file referenced by the manifest, if any. It
+// must be called after loadManifest.
+func loadPolicy(manifest *manifest2.Manifest) (*policy.Policy, error) {
+	if manifest.Policy == "" {
+		return nil, nil
+	}
+
+	pol, err := manifest2.ReadPolicyFromFile(manifest.Policy)
+	if err != nil {
+		return nil, errors.Wrap(err, "read policy")
+	}
+
+	return pol, nil
+}
+
 func findManifest(startdir string) (result string, err error) {
 	manifestName := ".cftool.yml"
 
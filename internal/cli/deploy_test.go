@@ -2,6 +2,7 @@ package cli
 
 import (
 	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftool"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -37,3 +38,22 @@ func TestFindManifest(t *testing.T) {
 		require.Equal(t, manifestPath, result)
 	})
 }
+
+func TestNarrowRegion(t *testing.T) {
+	deployments := []*cftool.Deployment{
+		{StackName: "a", Region: "eu-west-1"},
+		{StackName: "b", Region: "us-east-1"},
+		{StackName: "c"},
+	}
+
+	result, err := narrowRegion(deployments, "")
+	require.NoError(t, err)
+	require.Equal(t, deployments, result)
+
+	result, err = narrowRegion(deployments, "us-east-1")
+	require.NoError(t, err)
+	require.Equal(t, []*cftool.Deployment{deployments[1], deployments[2]}, result)
+
+	_, err = narrowRegion(deployments[:1], "us-east-1")
+	require.EqualError(t, err, "no deployments in region us-east-1; selected deployments are in: eu-west-1")
+}
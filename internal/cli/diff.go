@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/internal"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"github.com/tetratom/cftool/pkg/pprint"
+	"io"
+	"io/ioutil"
+)
+
+// ErrDifferencesFound is returned by Diff if any stack differs from its
+// deployment.
+var ErrDifferencesFound = errors.New("differences found")
+
+// Diff compares deployments with their stacks, without creating change sets.
+func Diff(c context.Context, globalOpts GlobalOptions, diffOpts DiffOptions) error {
+	deployments, err := diffDeployments(globalOpts, diffOpts)
+	if err != nil {
+		return err
+	}
+
+	stsapi, err := globalOpts.AWS.STSClient()
+	if err != nil {
+		return err
+	}
+
+	differ := 0
+	for i, deployment := range deployments {
+		if i > 0 {
+			fmt.Fprint(color.Output, "\n")
+		}
+
+		if deployment.TenantLabel != "" {
+			pprint.Field(color.Output, "Tenant", deployment.TenantLabel)
+			pprint.Field(color.Output, "Stack", deployment.StackLabel)
+		}
+
+		api, err := globalOpts.AWS.CloudFormationClient(deployment.Region)
+		if err != nil {
+			return err
+		}
+
+		deployer := internal.NewDeployer(api, deployment)
+		deployer.Events = globalOpts.events
+
+		id, err := deployer.Whoami(color.Output, stsapi, getRegion(api))
+		if err != nil {
+			return err
+		}
+
+		if deployment.AccountId != "" && deployment.AccountId != *id.Account {
+			return errors.Errorf(
+				"tenant %s expects account %s, but the current account is %s",
+				deployment.TenantLabel, deployment.AccountId, *id.Account)
+		}
+
+		pprint.Field(color.Output, "StackName", deployment.StackName)
+
+		changed, err := deployer.Diff(color.Output)
+		if err != nil {
+			return errors.Wrapf(err, "diff stack: %s", deployment.StackName)
+		}
+
+		if changed {
+			differ++
+		}
+	}
+
+	return diffSummary(color.Output, differ, len(deployments))
+}
+
+// diffSummary prints how many of the stacks differ, and returns
+// ErrDifferencesFound if any do.
+func diffSummary(w io.Writer, differ int, total int) error {
+	fmt.Fprintf(w, "\n%d of %d stack(s) differ.\n", differ, total)
+
+	if differ > 0 {
+		return ErrDifferencesFound
+	}
+
+	return nil
+}
+
+// diffDeployments returns the single stack given by a template file, or the
+// deployments selected from the manifest.
+func diffDeployments(globalOpts GlobalOptions, diffOpts DiffOptions) ([]*cftool.Deployment, error) {
+	if diffOpts.TemplateFile != "" {
+		updateOpts := UpdateOptions{
+			Parameters:     diffOpts.Parameters,
+			ParameterFiles: diffOpts.ParameterFiles,
+			StackName:      diffOpts.StackName,
+			TemplateFile:   diffOpts.TemplateFile,
+		}
+
+		stackName, err := deriveStackName(updateOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		params, err := parseParameters(updateOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		templateBody, err := ioutil.ReadFile(diffOpts.TemplateFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read template: %s", diffOpts.TemplateFile)
+		}
+
+		return []*cftool.Deployment{{
+			TemplatePath: diffOpts.TemplateFile,
+			TemplateBody: templateBody,
+			Parameters:   params.Parameters,
+			StackTags:    params.Tags,
+			StackName:    string(stackName),
+		}}, nil
+	}
+
+	manifest, manifestPath, err := loadManifest(diffOpts.ManifestFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pprint.Field(color.Output, "Manifest", manifestPath)
+
+	deployments, err := manifest.FindDeployments(diffOpts.Tenants, diffOpts.Stacks)
+	if err != nil {
+		return nil, err
+	}
+
+	return narrowRegion(deployments, globalOpts.AWS.Region)
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tetratom/cftool/internal"
+	"testing"
+)
+
+func TestDiffSummary(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, diffSummary(&buf, 0, 3))
+	assert.Equal(t, "\n0 of 3 stack(s) differ.\n", buf.String())
+
+	buf.Reset()
+	assert.Equal(t, ErrDifferencesFound, diffSummary(&buf, 2, 3))
+	assert.Equal(t, "\n2 of 3 stack(s) differ.\n", buf.String())
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, exitCode(nil))
+	assert.Equal(t, 2, exitCode(ErrDifferencesFound))
+	assert.Equal(t, 2, exitCode(errors.Wrap(ErrDifferencesFound, "diff")))
+	assert.Equal(t, 3, exitCode(errors.Wrap(internal.ErrNonInteractive, "MFA token required")))
+	assert.Equal(t, 1, exitCode(internal.ErrAbortedByUser))
+	assert.Equal(t, 1, exitCode(errors.New("describe stack")))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
 	"github.com/tetratom/cftool/internal"
+	"github.com/tetratom/cftool/internal/events"
 	"github.com/tetratom/cftool/pkg/pprint"
 	"os"
 	"runtime"
@@ -30,9 +31,15 @@ func Entry(c context.Context, args []string) error {
 		return nil
 	}
 
+	if options.Output == "json" {
+		// Human output moves to stderr, leaving stdout to the event stream.
+		color.Output = color.Error
+		options.events = events.NewStream(os.Stdout)
+	}
+
 	if len(options.remainingArgs) < 1 {
 		flag.Usage()
-		fmt.Fprintf(color.Output, "\nExpected subcommand: deploy, update\n")
+		fmt.Fprintf(color.Output, "\nExpected subcommand: deploy, plan, report, update, diff, list, render, validate, manifest, params\n")
 		os.Exit(1) // TODO: Return error instead?
 	}
 
@@ -40,20 +47,95 @@ func Entry(c context.Context, args []string) error {
 	switch subcommand := options.remainingArgs[0]; subcommand {
 	case "deploy":
 		err = Deploy(c, options, ParseDeployOptions(options.remainingArgs))
+	case "plan":
+		err = Plan(c, options, ParsePlanOptions(options.remainingArgs))
+	case "report":
+		err = Report(c, options, ParseReportOptions(options.remainingArgs))
 	case "update":
 		err = Update(c, options, ParseUpdateOptions(options.remainingArgs))
+	case "diff":
+		err = Diff(c, options, ParseDiffOptions(options.remainingArgs))
+	case "list":
+		err = List(c, options, ParseListOptions(options.remainingArgs))
+	case "render":
+		err = Render(c, options, ParseRenderOptions(options.remainingArgs))
+	case "validate":
+		err = Validate(c, options, ParseValidateOptions(options.remainingArgs))
+	case "manifest":
+		err = manifestSubcommand(c, options, options.remainingArgs[1:])
+	case "params":
+		err = paramsSubcommand(c, options, options.remainingArgs[1:])
 	default:
 		// todo: where to output to?
 		fmt.Fprintf(color.Output, "\nUnrecognized subcommand: %s\n", subcommand)
 	}
 
-	if err != nil {
-		if errors.Cause(err) == internal.ErrAbortedByUser {
-			fmt.Fprintf(color.Output, "Aborted by user.\n")
-			os.Exit(1)
-		}
+	switch code := exitCode(err); code {
+	case 0:
+		return nil
+
+	case 2:
+		os.Exit(code)
+
+	case 3:
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(code)
+	}
+
+	options.events.Emit(events.Event{
+		Type:    events.TypeError,
+		Message: err.Error(),
+	})
+
+	if errors.Cause(err) == internal.ErrAbortedByUser {
+		fmt.Fprintf(color.Output, "Aborted by user.\n")
+		os.Exit(1)
+	}
+
+	return err
+}
 
-		return err
+// requireStdout returns an error if subcommand cannot write its result to
+// standard output, because --output json reserves it for the event stream.
+func requireStdout(globalOpts GlobalOptions, subcommand string) error {
+	if globalOpts.Output != "json" {
+		return nil
+	}
+
+	return errors.Errorf(
+		"%s cannot write to standard output with --output json, which reserves it for events",
+		subcommand)
+}
+
+// exitCode returns the exit status for the result of a subcommand: 2 if
+// stacks differ, which is not a failure, 3 if a confirmation was required in
+// non-interactive mode, and 1 for any other error.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Cause(err) == ErrDifferencesFound:
+		return 2
+	case internal.IsNonInteractive(err):
+		return 3
+	default:
+		return 1
+	}
+}
+
+func manifestSubcommand(c context.Context, options GlobalOptions, args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintf(color.Output, "\nExpected subcommand: manifest migrate, manifest import\n")
+		os.Exit(1)
+	}
+
+	switch subcommand := args[0]; subcommand {
+	case "migrate":
+		return Migrate(c, options, ParseMigrateOptions(args))
+	case "import":
+		return Import(c, options, ParseImportOptions(args))
+	default:
+		fmt.Fprintf(color.Output, "\nUnrecognized subcommand: manifest %s\n", subcommand)
 	}
 
 	return nil
@@ -0,0 +1,454 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	manifest2 "github.com/tetratom/cftool/pkg/manifest"
+	"github.com/tetratom/cftool/pkg/pprint"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// regionPlaceholder replaces a region name within an imported stack name.
+const regionPlaceholder = "{{.Region}}"
+
+// importedStack is an existing stack read by the import subcommand.
+type importedStack struct {
+	Name         string
+	Region       string
+	TemplateBody string
+	Parameters   map[string]string
+	Tags         map[string]string
+}
+
+// importGroup is a set of imported stacks that become targets of a single
+// manifest stack: those whose names are equal once the region is factored
+// out.
+type importGroup struct {
+	Label     string
+	StackName string
+	Stacks    []*importedStack
+}
+
+// The following mirror the manifest schema, and determine the order in which
+// properties of a generated manifest are written.
+type importManifest struct {
+	Version string             `yaml:"Version"`
+	Tenants []importTenant     `yaml:"Tenants"`
+	Stacks  []importStackEntry `yaml:"Stacks"`
+}
+
+type importTenant struct {
+	Label   string         `yaml:"Label"`
+	Default importDefaults `yaml:"Default"`
+}
+
+type importStackEntry struct {
+	Label   string         `yaml:"Label"`
+	Default importDefaults `yaml:"Default"`
+	Targets []importTarget `yaml:"Targets"`
+}
+
+type importTarget struct {
+	Tenant   string          `yaml:"Tenant"`
+	Override *importDefaults `yaml:"Override,omitempty"`
+}
+
+type importParameter struct {
+	File     string `yaml:"File"`
+	Optional bool   `yaml:"Optional"`
+}
+
+type importDefaults struct {
+	AccountId  string            `yaml:"AccountId,omitempty"`
+	Region     string            `yaml:"Region,omitempty"`
+	Regions    []string          `yaml:"Regions,omitempty,flow"`
+	Template   string            `yaml:"Template,omitempty"`
+	StackName  string            `yaml:"StackName,omitempty"`
+	Parameters []importParameter `yaml:"Parameters,omitempty"`
+}
+
+// importConfiguration is the template configuration written for a stack with
+// tags, as only the tags of template configurations are applied to stacks.
+type importConfiguration struct {
+	Parameters map[string]string
+	Tags       map[string]string
+}
+
+// Import generates a manifest, templates and parameter files from the stacks
+// that exist in the current account.
+func Import(c context.Context, globalOpts GlobalOptions, importOpts ImportOptions) error {
+	manifestPath := filepath.Join(importOpts.Directory, ".cftool.yml")
+	if ok, err := fileExists(manifestPath); err != nil {
+		return err
+	} else if ok {
+		return errors.Errorf("manifest %s already exists", manifestPath)
+	}
+
+	stsapi, err := globalOpts.AWS.STSClient()
+	if err != nil {
+		return err
+	}
+
+	id, err := stsapi.GetCallerIdentityWithContext(c, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return errors.Wrap(err, "get caller identity")
+	}
+
+	regions := importOpts.Regions
+	if len(regions) == 0 {
+		regions = []string{globalOpts.AWS.Region}
+	}
+
+	var stacks []*importedStack
+	for _, region := range regions {
+		found, err := importStacks(c, globalOpts, region, importOpts.Stacks)
+		if err != nil {
+			return err
+		}
+
+		stacks = append(stacks, found...)
+	}
+
+	if len(stacks) == 0 {
+		return errors.New("no stacks found to import")
+	}
+
+	groups := groupImportedStacks(stacks)
+
+	files, doc, err := renderImport(importOpts.Tenant, aws.StringValue(id.Account), groups)
+	if err != nil {
+		return err
+	}
+
+	if _, err := manifest2.Read(strings.NewReader(string(doc))); err != nil {
+		return errors.Wrap(err, "generated manifest is invalid")
+	}
+
+	files[".cftool.yml"] = doc
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		target := filepath.Join(importOpts.Directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(target, files[name], 0644); err != nil {
+			return errors.Wrapf(err, "write %s", target)
+		}
+	}
+
+	pprint.Field(color.Output, "Manifest", manifestPath)
+	fmt.Fprintf(
+		color.Output,
+		"\nImported %d stack(s) as %d manifest stack(s).\n",
+		len(stacks), len(groups))
+
+	return nil
+}
+
+// importStacks reads the stacks of a region whose names match any of the
+// patterns. Nested stacks, and stacks that have no template yet, are
+// skipped.
+func importStacks(
+	c context.Context,
+	globalOpts GlobalOptions,
+	region string,
+	patterns []string,
+) ([]*importedStack, error) {
+	api, err := globalOpts.AWS.CloudFormationClient(region)
+	if err != nil {
+		return nil, err
+	}
+
+	region = getRegion(api)
+
+	var stacks []*cloudformation.Stack
+	err = api.DescribeStacksPagesWithContext(
+		c,
+		&cloudformation.DescribeStacksInput{},
+		func(page *cloudformation.DescribeStacksOutput, lastPage bool) bool {
+			stacks = append(stacks, page.Stacks...)
+			return true
+		})
+	if err != nil {
+		return nil, errors.Wrapf(err, "describe stacks in %s", region)
+	}
+
+	var result []*importedStack
+	for _, stack := range stacks {
+		name := aws.StringValue(stack.StackName)
+
+		if stack.ParentId != nil ||
+			aws.StringValue(stack.StackStatus) == cloudformation.StackStatusReviewInProgress {
+			continue
+		}
+
+		if ok, err := matchAnyPattern(patterns, name); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		template, err := api.GetTemplateWithContext(c, &cloudformation.GetTemplateInput{
+			StackName:     stack.StackId,
+			TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "get template of stack %s in %s", name, region)
+		}
+
+		body := aws.StringValue(template.TemplateBody)
+
+		parsed, err := cftemplate.Parse([]byte(body))
+		if err != nil {
+			return nil, errors.Wrapf(err, "stack %s in %s", name, region)
+		}
+
+		params, noEcho := exportParameters(
+			stack.Parameters, parameterDeclarations(parsed), false)
+		if len(noEcho) > 0 {
+			pprint.Warningf(
+				color.Output,
+				"Stack %s in %s: NoEcho parameters must be filled in by hand: %s",
+				name, region, strings.Join(noEcho, ", "))
+		}
+
+		result = append(result, &importedStack{
+			Name:         name,
+			Region:       region,
+			TemplateBody: body,
+			Parameters:   params,
+			Tags:         importTags(stack.Tags),
+		})
+	}
+
+	return result, nil
+}
+
+// importTags maps the tags of a stack to their values, leaving out those that
+// CloudFormation applies itself. Values are escaped, as manifests template
+// the parameter files they are written to.
+func importTags(tags []*cloudformation.Tag) map[string]string {
+	result := make(map[string]string)
+	for _, tag := range tags {
+		if key := aws.StringValue(tag.Key); !strings.HasPrefix(key, "aws:") {
+			result[key] = manifest2.EscapeTemplate(aws.StringValue(tag.Value))
+		}
+	}
+
+	return result
+}
+
+func matchAnyPattern(patterns []string, name string) (bool, error) {
+	if len(patterns) == 0 {
+		return true, nil
+	}
+
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, errors.Wrapf(err, "pattern %s", pattern)
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parameterDeclarations converts the parameters of a parsed template into
+// the form returned by CloudFormation.
+func parameterDeclarations(t *cftemplate.Template) []*cloudformation.ParameterDeclaration {
+	result := make([]*cloudformation.ParameterDeclaration, len(t.Parameters))
+	for i, p := range t.Parameters {
+		result[i] = &cloudformation.ParameterDeclaration{
+			ParameterKey: aws.String(p.Name),
+			DefaultValue: p.Default,
+			NoEcho:       aws.Bool(p.NoEcho),
+		}
+	}
+
+	return result
+}
+
+// groupImportedStacks groups stacks whose names are equal once the name of
+// their region is replaced by a placeholder, such that a stack deployed to
+// several regions under region-specific names becomes one manifest stack.
+// Each group is given a unique label derived from its name.
+func groupImportedStacks(stacks []*importedStack) []*importGroup {
+	var groups []*importGroup
+	byName := make(map[string]*importGroup)
+
+	for _, stack := range stacks {
+		name := strings.Replace(stack.Name, stack.Region, regionPlaceholder, -1)
+
+		group, ok := byName[name]
+		if !ok {
+			group = &importGroup{StackName: name}
+			byName[name] = group
+			groups = append(groups, group)
+		}
+
+		group.Stacks = append(group.Stacks, stack)
+	}
+
+	labels := make(map[string]bool)
+	for _, group := range groups {
+		base := importLabel(group.StackName)
+		label := base
+		for i := 2; labels[label]; i++ {
+			label = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		labels[label] = true
+		group.Label = label
+	}
+
+	return groups
+}
+
+// importLabel derives a stack label from a stack name by removing the region
+// placeholder and any separators it leaves behind.
+func importLabel(name string) string {
+	label := name
+	for _, sep := range []string{"-", "_", "."} {
+		label = strings.Replace(label, sep+regionPlaceholder, "", -1)
+		label = strings.Replace(label, regionPlaceholder+sep, "", -1)
+	}
+
+	label = strings.Replace(label, regionPlaceholder, "", -1)
+	if label == "" {
+		return "stack"
+	}
+
+	return label
+}
+
+// renderImport returns the manifest for the groups, and the templates and
+// parameter files it refers to, keyed by slash-separated path.
+func renderImport(
+	tenant string,
+	accountId string,
+	groups []*importGroup,
+) (files map[string][]byte, doc []byte, err error) {
+	files = make(map[string][]byte)
+
+	m := importManifest{
+		Version: manifest2.SupportedVersion,
+		Tenants: []importTenant{{
+			Label:   tenant,
+			Default: importDefaults{AccountId: accountId},
+		}},
+	}
+
+	for _, group := range groups {
+		entry := importStackEntry{
+			Label: group.Label,
+			Default: importDefaults{
+				StackName: group.StackName,
+			},
+		}
+
+		ext := ".yml"
+		if strings.HasPrefix(strings.TrimSpace(group.Stacks[0].TemplateBody), "{") {
+			ext = ".json"
+		}
+
+		if sameTemplates(group.Stacks) {
+			entry.Default.Template = "templates/" + group.Label + ext
+			files[entry.Default.Template] = []byte(group.Stacks[0].TemplateBody)
+		} else {
+			entry.Default.Template = "templates/" + group.Label + "-" + regionPlaceholder + ext
+			for _, stack := range group.Stacks {
+				name := "templates/" + group.Label + "-" + stack.Region + ext
+				files[name] = []byte(stack.TemplateBody)
+			}
+		}
+
+		hasParameters := false
+		for _, stack := range group.Stacks {
+			if len(stack.Parameters) == 0 && len(stack.Tags) == 0 {
+				continue
+			}
+
+			var content interface{} = stack.Parameters
+			if len(stack.Tags) > 0 {
+				config := importConfiguration{Parameters: stack.Parameters, Tags: stack.Tags}
+				if config.Parameters == nil {
+					config.Parameters = make(map[string]string)
+				}
+
+				content = config
+			}
+
+			data, err := json.MarshalIndent(content, "", "  ")
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "marshal parameters of stack %s", stack.Name)
+			}
+
+			files["stacks/"+group.Label+"/"+stack.Region+".json"] = append(data, '\n')
+			hasParameters = true
+		}
+
+		if hasParameters {
+			entry.Default.Parameters = []importParameter{{
+				File:     "stacks/" + group.Label + "/" + regionPlaceholder + ".json",
+				Optional: true,
+			}}
+		}
+
+		override := &importDefaults{}
+		if len(group.Stacks) == 1 {
+			override.Region = group.Stacks[0].Region
+		} else {
+			for _, stack := range group.Stacks {
+				override.Regions = append(override.Regions, stack.Region)
+			}
+		}
+
+		entry.Targets = []importTarget{{Tenant: tenant, Override: override}}
+		m.Stacks = append(m.Stacks, entry)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return nil, nil, errors.Wrap(err, "marshal manifest")
+	}
+
+	return files, buf.Bytes(), nil
+}
+
+func sameTemplates(stacks []*importedStack) bool {
+	for _, stack := range stacks[1:] {
+		if stack.TemplateBody != stacks[0].TemplateBody {
+			return false
+		}
+	}
+
+	return true
+}
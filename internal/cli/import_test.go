@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/manifest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGroupImportedStacks(t *testing.T) {
+	groups := groupImportedStacks([]*importedStack{
+		{Name: "app-eu-west-1", Region: "eu-west-1"},
+		{Name: "app-us-east-1", Region: "us-east-1"},
+		{Name: "eu-west-1-network", Region: "eu-west-1"},
+		{Name: "app", Region: "eu-west-1"},
+		{Name: "eu-west-1", Region: "eu-west-1"},
+	})
+
+	var actual []string
+	for _, g := range groups {
+		actual = append(actual, g.Label+" "+g.StackName)
+	}
+
+	assert.Equal(t, []string{
+		"app app-{{.Region}}",
+		"network {{.Region}}-network",
+		"app-2 app",
+		"stack {{.Region}}",
+	}, actual)
+	assert.Len(t, groups[0].Stacks, 2)
+}
+
+func TestRenderImport(t *testing.T) {
+	template := "Parameters:\n  Size:\n    Type: String\n"
+
+	// Values are imported as importStacks does, including ones that look
+	// like templates.
+	params, _ := exportParameters([]*cloudformation.Parameter{
+		{ParameterKey: aws.String("Size"), ParameterValue: aws.String("{{small}}")},
+	}, nil, false)
+	tags := importTags([]*cloudformation.Tag{
+		{Key: aws.String("Team"), Value: aws.String("{{a}}")},
+		{Key: aws.String("aws:cloudformation:stack-name"), Value: aws.String("app-us-east-1")},
+	})
+
+	groups := groupImportedStacks([]*importedStack{
+		{
+			Name:         "app-eu-west-1",
+			Region:       "eu-west-1",
+			TemplateBody: template,
+			Parameters:   map[string]string{"Size": "large"},
+			Tags:         map[string]string{"Team": "a", "Region": "eu"},
+		},
+		{
+			Name:         "app-us-east-1",
+			Region:       "us-east-1",
+			TemplateBody: template,
+			Parameters:   params,
+			Tags:         tags,
+		},
+		{
+			Name:         "network",
+			Region:       "eu-west-1",
+			TemplateBody: `{"Resources": {}}`,
+		},
+	})
+
+	files, doc, err := renderImport("main", "111111111111", groups)
+	require.NoError(t, err)
+
+	assert.Equal(t, `Version: "1.2"
+Tenants:
+- Label: main
+  Default:
+    AccountId: "111111111111"
+Stacks:
+- Label: app
+  Default:
+    Template: templates/app.yml
+    StackName: app-{{.Region}}
+    Parameters:
+    - File: stacks/app/{{.Region}}.json
+      Optional: true
+  Targets:
+  - Tenant: main
+    Override:
+      Regions: [eu-west-1, us-east-1]
+- Label: network
+  Default:
+    Template: templates/network.json
+    StackName: network
+  Targets:
+  - Tenant: main
+    Override:
+      Region: eu-west-1
+`, string(doc))
+
+	dir, err := ioutil.TempDir("", "cftool-import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files[".cftool.yml"] = doc
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, data, 0644))
+	}
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(cwd)
+	require.NoError(t, os.Chdir(dir))
+
+	m, err := manifest.ReadFromFile(".cftool.yml")
+	require.NoError(t, err)
+
+	deployments, err := m.FindDeployments([]string{"main"}, []string{"*"})
+	require.NoError(t, err)
+	require.Len(t, deployments, 3)
+
+	assert.Equal(t, "app-us-east-1", deployments[1].StackName)
+	assert.Equal(t, map[string]string{"Size": "large"}, deployments[0].Parameters)
+	assert.Equal(t, map[string]string{"Team": "a", "Region": "eu"}, deployments[0].StackTags)
+	assert.Equal(t, map[string]string{"Size": "{{small}}"}, deployments[1].Parameters)
+	assert.Equal(t, map[string]string{"Team": "{{a}}"}, deployments[1].StackTags)
+	assert.Empty(t, deployments[1].Tags)
+	assert.Equal(t, "network", deployments[2].StackName)
+	assert.Empty(t, deployments[2].Parameters)
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+type listEntry struct {
+	Tenant    string
+	Stack     string
+	StackName string
+	Region    string
+	AccountId string
+	Template  string
+	Protected bool
+}
+
+func List(c context.Context, globalOpts GlobalOptions, listOpts ListOptions) error {
+	if err := requireStdout(globalOpts, "list"); err != nil {
+		return err
+	}
+
+	manifest, _, err := loadManifest(listOpts.ManifestFile)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := manifest.FindDeployments(listOpts.Tenants, listOpts.Stacks)
+	if err != nil {
+		return err
+	}
+
+	deployments, err = narrowRegion(deployments, globalOpts.AWS.Region)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]listEntry, len(deployments))
+	for i, d := range deployments {
+		entries[i] = listEntry{
+			Tenant:    d.TenantLabel,
+			Stack:     d.StackLabel,
+			StackName: d.StackName,
+			Region:    d.Region,
+			AccountId: d.AccountId,
+			Template:  d.TemplatePath,
+			Protected: d.Protected,
+		}
+	}
+
+	switch listOpts.Format {
+	case "json", "yaml":
+		return writeStructured(os.Stdout, listOpts.Format, entries)
+	default:
+		return writeListTable(os.Stdout, deployments)
+	}
+}
+
+func writeListTable(w io.Writer, deployments []*cftool.Deployment) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TENANT\tSTACK\tSTACK NAME\tREGION\tACCOUNT\tTEMPLATE\tPROTECTED\n")
+
+	for _, d := range deployments {
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%t\n",
+			d.TenantLabel,
+			d.StackLabel,
+			d.StackName,
+			orDash(d.Region),
+			orDash(d.AccountId),
+			d.TemplatePath,
+			d.Protected)
+	}
+
+	return tw.Flush()
+}
+
+// writeStructured writes v as indented JSON or as YAML.
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	var data []byte
+	var err error
+
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = yaml.Marshal(v)
+	default:
+		return errors.Errorf("unknown output format: %s", format)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "marshal %s", format)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"strings"
+	"testing"
+)
+
+func TestWriteListTable(t *testing.T) {
+	w := &strings.Builder{}
+	deployments := []*cftool.Deployment{
+		{
+			TenantLabel:  "live",
+			StackLabel:   "network",
+			StackName:    "live-network",
+			Region:       "eu-west-1",
+			AccountId:    "111111111111",
+			TemplatePath: "templates/network.yml",
+			Protected:    true,
+		},
+		{
+			TenantLabel:  "test",
+			StackLabel:   "network",
+			StackName:    "test-network",
+			TemplatePath: "templates/network.yml",
+		},
+	}
+
+	require.NoError(t, writeListTable(w, deployments))
+	require.Equal(t, `TENANT  STACK    STACK NAME    REGION     ACCOUNT       TEMPLATE               PROTECTED
+live    network  live-network  eu-west-1  111111111111  templates/network.yml  true
+test    network  test-network  -          -             templates/network.yml  false
+`, w.String())
+}
+
+func TestRequireStdout(t *testing.T) {
+	require.NoError(t, requireStdout(GlobalOptions{Output: "text"}, "list"))
+	require.EqualError(
+		t, requireStdout(GlobalOptions{Output: "json"}, "list"),
+		"list cannot write to standard output with --output json, which reserves it for events")
+}
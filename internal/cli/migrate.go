@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	manifest2 "github.com/tetratom/cftool/pkg/manifest"
+	"github.com/tetratom/cftool/pkg/pprint"
+	"io/ioutil"
+	"os"
+)
+
+// Migrate rewrites a manifest to the newest manifest version.
+func Migrate(c context.Context, globalOpts GlobalOptions, migrateOpts MigrateOptions) error {
+	if migrateOpts.OutputFile == "-" {
+		if err := requireStdout(globalOpts, "manifest migrate"); err != nil {
+			return err
+		}
+	}
+
+	path := migrateOpts.ManifestFile
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		path, err = findManifest(cwd)
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	doc, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "read manifest %s", path)
+	}
+
+	result, from, err := manifest2.Migrate(doc)
+	if err != nil {
+		return errors.Wrapf(err, "migrate manifest %s", path)
+	}
+
+	if migrateOpts.OutputFile == "-" {
+		_, err = os.Stdout.Write(result)
+		return err
+	}
+
+	output := path
+	if migrateOpts.OutputFile != "" {
+		output = migrateOpts.OutputFile
+	}
+
+	if err := ioutil.WriteFile(output, result, info.Mode()); err != nil {
+		return errors.Wrapf(err, "write manifest %s", output)
+	}
+
+	pprint.Field(color.Output, "Manifest", output)
+	fmt.Fprintf(
+		color.Output,
+		"\nMigrated from version %s to version %s.\n",
+		from, manifest2.SupportedVersion)
+
+	return nil
+}
@@ -9,9 +9,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/mattn/go-isatty"
 	"github.com/pborman/getopt/v2"
 	"github.com/pkg/errors"
 	"github.com/tetratom/cftool/internal"
+	"github.com/tetratom/cftool/internal/events"
 	"os"
 	"time"
 )
@@ -20,7 +22,15 @@ type GlobalOptions struct {
 	AWS           AWSOptions
 	Color         bool
 	Version       bool
+	Output        string
 	remainingArgs []string
+
+	// prompter asks for confirmations, failing instead if cftool runs
+	// non-interactively.
+	prompter *internal.Prompter
+
+	// events receives the machine-readable output when Output is "json".
+	events *events.Stream
 }
 
 type AWSOptions struct {
@@ -28,8 +38,11 @@ type AWSOptions struct {
 	Region   string
 	Endpoint string
 
+	// NonInteractive prevents reading MFA tokens from standard input.
+	NonInteractive bool
+
 	sess *session.Session
-	cfn  cloudformationiface.CloudFormationAPI
+	cfn  map[string]cloudformationiface.CloudFormationAPI
 	sts  stsiface.STSAPI
 }
 
@@ -38,6 +51,11 @@ func (awsOpts *AWSOptions) Session() (*session.Session, error) {
 		opts := session.Options{}
 		opts.SharedConfigState = session.SharedConfigEnable
 		opts.AssumeRoleTokenProvider = stscreds.StdinTokenProvider
+		if awsOpts.NonInteractive {
+			opts.AssumeRoleTokenProvider = func() (string, error) {
+				return "", errors.Wrap(internal.ErrNonInteractive, "MFA token required")
+			}
+		}
 		opts.AssumeRoleDuration = 1 * time.Hour // todo: configurable?
 
 		if awsOpts.Profile != "" {
@@ -67,7 +85,7 @@ func (awsOpts *AWSOptions) Session() (*session.Session, error) {
 }
 
 func (awsOpts *AWSOptions) CloudFormationClient(region string) (cloudformationiface.CloudFormationAPI, error) {
-	if awsOpts.cfn == nil {
+	if awsOpts.cfn[region] == nil {
 		sess, err := awsOpts.Session()
 		if err != nil {
 			return nil, err
@@ -82,10 +100,14 @@ func (awsOpts *AWSOptions) CloudFormationClient(region string) (cloudformationif
 			config = append(config, &aws.Config{Region: &region})
 		}
 
-		awsOpts.cfn = cloudformation.New(sess, config...)
+		if awsOpts.cfn == nil {
+			awsOpts.cfn = make(map[string]cloudformationiface.CloudFormationAPI)
+		}
+
+		awsOpts.cfn[region] = cloudformation.New(sess, config...)
 	}
 
-	return awsOpts.cfn, nil
+	return awsOpts.cfn[region], nil
 }
 
 func (awsOpts *AWSOptions) STSClient() (stsiface.STSAPI, error) {
@@ -112,10 +134,21 @@ func ParseGlobalOptions(args []string) GlobalOptions {
 	color := flags.EnumLong(
 		"color", 'c', []string{"on", "off"}, "on",
 		"'on' or 'off'. pass 'off' to disable colors.")
+	output := flags.EnumLong(
+		"output", 'o', []string{"text", "json"}, "text",
+		"'text' or 'json'. pass 'json' for newline-delimited JSON events on stdout.")
+	nonInteractive := flags.BoolLong(
+		"non-interactive", 0,
+		"never prompt; fail unless approved with --approve (default if stdin is not a terminal)")
+	var approve []string
+	flags.FlagLong(
+		&approve, "approve", 0,
+		"confirmations to approve in non-interactive mode: create, execute, policy, delete-failed")
 	flags.FlagLong(&options.Version, "version", 'V', "show version and exit")
 	flags.SetProgram("cftool")
 	flags.Parse(args)
 	options.Color = color == nil || *color == "on"
+	options.Output = *output
 	options.remainingArgs = flags.Args()
 
 	if *showHelp {
@@ -123,14 +156,32 @@ func ParseGlobalOptions(args []string) GlobalOptions {
 		os.Exit(0)
 	}
 
+	approved, err := internal.ParseApprovals(approve)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	options.prompter = &internal.Prompter{
+		NonInteractive: *nonInteractive || !isTerminal(os.Stdin),
+		Approved:       approved,
+	}
+
+	options.AWS.NonInteractive = options.prompter.NonInteractive
+
 	return options
 }
 
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
 type DeployOptions struct {
 	Yes          bool
 	ManifestFile string
-	Stack        string
-	Tenant       string
+	Stacks       []string
+	Tenants      []string
 	ShowDiff     bool
 }
 
@@ -140,8 +191,8 @@ func ParseDeployOptions(args []string) DeployOptions {
 	flags := getopt.New()
 	flags.FlagLong(&options.Yes, "yes", 'y', "do not prompt for confirmation")
 	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
-	flags.FlagLong(&options.Stack, "stack", 's', "stack to deploy")
-	flags.FlagLong(&options.Tenant, "tenant", 't', "tenant to deploy for")
+	flags.FlagLong(&options.Stacks, "stack", 's', "stacks to deploy (label or pattern)")
+	flags.FlagLong(&options.Tenants, "tenant", 't', "tenants to deploy for (label, pattern or group)")
 	showDiff := flags.BoolLong("diff", 'd', "show template diff when updating a stack")
 	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
 	flags.SetProgram("cftool [options ...] deploy")
@@ -163,6 +214,379 @@ func ParseDeployOptions(args []string) DeployOptions {
 	return options
 }
 
+type DiffOptions struct {
+	ManifestFile   string
+	Stacks         []string
+	Tenants        []string
+	StackName      string
+	TemplateFile   string
+	Parameters     []string
+	ParameterFiles []string
+}
+
+func ParseDiffOptions(args []string) DiffOptions {
+	var options DiffOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
+	flags.FlagLong(&options.Stacks, "stack", 's', "stacks to compare (label or pattern)")
+	flags.FlagLong(&options.Tenants, "tenant", 't', "tenants to compare for (label, pattern or group)")
+	flags.FlagLong(&options.TemplateFile, "template-file", 'T', "compare a single stack with this template instead of using a manifest")
+	flags.FlagLong(&options.StackName, "stack-name", 'n', "override inferred stack name (with --template-file)")
+	flags.FlagLong(&options.Parameters, "parameter", 'P', "explicit parameters (with --template-file)")
+	flags.FlagLong(&options.ParameterFiles, "parameter-file", 'p', "path to parameter file (with --template-file)")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] diff")
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(options.Stacks) == 0 {
+		options.Stacks = []string{"*"}
+	}
+
+	if len(options.Tenants) == 0 {
+		options.Tenants = []string{"*"}
+	}
+
+	return options
+}
+
+type PlanOptions struct {
+	ManifestFile string
+	Stacks       []string
+	Tenants      []string
+	ShowDiff     bool
+	ReportFile   string
+}
+
+func ParsePlanOptions(args []string) PlanOptions {
+	var options PlanOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
+	flags.FlagLong(&options.Stacks, "stack", 's', "stacks to plan (label or pattern)")
+	flags.FlagLong(&options.Tenants, "tenant", 't', "tenants to plan for (label, pattern or group)")
+	flags.FlagLong(&options.ReportFile, "report", 'R', "write a Markdown report of the change sets to this path ('-' for stdout)")
+	showDiff := flags.BoolLong("diff", 'd', "show template diff when updating a stack")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] plan")
+	flags.Parse(args)
+	options.ShowDiff = *showDiff
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	return options
+}
+
+type ReportOptions struct {
+	StackName     string
+	ChangeSetName string
+	OutputFile    string
+}
+
+func ParseReportOptions(args []string) ReportOptions {
+	var options ReportOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.StackName, "stack-name", 'n', "stack of the change set (unless given by ARN)")
+	flags.FlagLong(&options.ChangeSetName, "change-set", 'c', "name or ARN of the change set")
+	flags.FlagLong(&options.OutputFile, "output-file", 'o', "write to this path instead of stdout")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] report")
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if options.ChangeSetName == "" {
+		fmt.Printf("error: --change-set is required.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	return options
+}
+
+type ListOptions struct {
+	ManifestFile string
+	Stacks       []string
+	Tenants      []string
+	Format       string
+}
+
+func ParseListOptions(args []string) ListOptions {
+	var options ListOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
+	flags.FlagLong(&options.Stacks, "stack", 's', "stacks to list (label or pattern)")
+	flags.FlagLong(&options.Tenants, "tenant", 't', "tenants to list (label, pattern or group)")
+	format := flags.EnumLong(
+		"format", 'F', []string{"table", "json", "yaml"}, "table",
+		"'table', 'json' or 'yaml'")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] list")
+	flags.Parse(args)
+	options.Format = *format
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if len(options.Stacks) == 0 {
+		options.Stacks = []string{"*"}
+	}
+
+	if len(options.Tenants) == 0 {
+		options.Tenants = []string{"*"}
+	}
+
+	return options
+}
+
+type RenderOptions struct {
+	ManifestFile string
+	Stack        string
+	Tenant       string
+	Format       string
+}
+
+func ParseRenderOptions(args []string) RenderOptions {
+	var options RenderOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
+	flags.FlagLong(&options.Stack, "stack", 's', "stack to render")
+	flags.FlagLong(&options.Tenant, "tenant", 't', "tenant to render for")
+	format := flags.EnumLong(
+		"format", 'F', []string{"yaml", "json"}, "yaml",
+		"'yaml' or 'json'")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] render")
+	flags.Parse(args)
+	options.Format = *format
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	return options
+}
+
+type ValidateOptions struct {
+	ManifestFile string
+}
+
+func ParseValidateOptions(args []string) ValidateOptions {
+	var options ValidateOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] validate")
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	return options
+}
+
+type MigrateOptions struct {
+	ManifestFile string
+	OutputFile   string
+}
+
+func ParseMigrateOptions(args []string) MigrateOptions {
+	var options MigrateOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.ManifestFile, "manifest", 'f', "manifest path")
+	flags.FlagLong(&options.OutputFile, "output-file", 'o', "write to this path instead ('-' for stdout)")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] manifest migrate")
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	return options
+}
+
+type ImportOptions struct {
+	Directory string
+	Tenant    string
+	Regions   []string
+	Stacks    []string
+}
+
+func ParseImportOptions(args []string) ImportOptions {
+	var options ImportOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.Directory, "directory", 'd', "directory to write the manifest, templates and parameter files to")
+	flags.FlagLong(&options.Tenant, "tenant", 't', "label of the tenant for the current account")
+	flags.FlagLong(&options.Regions, "regions", 'R', "regions to import stacks from (default: the current region)")
+	flags.FlagLong(&options.Stacks, "stack-name", 'n', "names of the stacks to import (name or pattern)")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] manifest import")
+	options.Directory = "."
+	options.Tenant = "default"
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	return options
+}
+
+type ParamsExportOptions struct {
+	StackName  string
+	OutputFile string
+	NonDefault bool
+}
+
+func ParseParamsExportOptions(args []string) ParamsExportOptions {
+	var options ParamsExportOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.StackName, "stack-name", 'n', "stack to export parameters from")
+	flags.FlagLong(&options.OutputFile, "output-file", 'o', "write to this path instead of stdout")
+	flags.FlagLong(&options.NonDefault, "non-default", 'D', "only export values that differ from the template defaults")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] params export")
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if options.StackName == "" {
+		fmt.Printf("error: --stack-name is required.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	return options
+}
+
+type ParamsInitOptions struct {
+	TemplateFile string
+	OutputFile   string
+}
+
+func ParseParamsInitOptions(args []string) ParamsInitOptions {
+	var options ParamsInitOptions
+
+	flags := getopt.New()
+	flags.FlagLong(&options.TemplateFile, "template-file", 't', "template file")
+	flags.FlagLong(&options.OutputFile, "output-file", 'o', "write to this path instead of stdout")
+	showHelp := flags.BoolLong("help", 'h', "show usage and exit")
+	flags.SetProgram("cftool [options ...] params init")
+	flags.Parse(args)
+	rest := flags.Args()
+
+	if len(rest) != 0 {
+		fmt.Printf("error: did not expect positional parameters.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	if *showHelp {
+		flags.PrintUsage(os.Stdout)
+		os.Exit(0)
+	}
+
+	if options.TemplateFile == "" {
+		fmt.Printf("error: --template-file is required.\n")
+		flags.PrintUsage(os.Stdout)
+		os.Exit(1)
+	}
+
+	return options
+}
+
 type UpdateOptions struct {
 	Parameters     []string
 	ParameterFiles []string
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/manifest"
+	"github.com/tetratom/cftool/pkg/pprint"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// NoEchoPlaceholder replaces the value of NoEcho parameters, which
+// CloudFormation does not reveal.
+const NoEchoPlaceholder = "<NoEcho>"
+
+func paramsSubcommand(c context.Context, options GlobalOptions, args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintf(color.Output, "\nExpected subcommand: params export, params init\n")
+		os.Exit(1)
+	}
+
+	switch subcommand := args[0]; subcommand {
+	case "export":
+		return ParamsExport(c, options, ParseParamsExportOptions(args))
+	case "init":
+		return ParamsInit(c, options, ParseParamsInitOptions(args))
+	default:
+		fmt.Fprintf(color.Output, "\nUnrecognized subcommand: params %s\n", subcommand)
+	}
+
+	return nil
+}
+
+// ParamsExport writes the current parameters of a stack as a parameter file.
+func ParamsExport(c context.Context, globalOpts GlobalOptions, exportOpts ParamsExportOptions) error {
+	if isStdout(exportOpts.OutputFile) {
+		if err := requireStdout(globalOpts, "params export"); err != nil {
+			return err
+		}
+	}
+
+	api, err := globalOpts.AWS.CloudFormationClient("")
+	if err != nil {
+		return err
+	}
+
+	stacks, err := api.DescribeStacksWithContext(c, &cloudformation.DescribeStacksInput{
+		StackName: aws.String(exportOpts.StackName),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "describe stack %s", exportOpts.StackName)
+	}
+
+	if len(stacks.Stacks) != 1 {
+		return errors.Errorf("stack %s not found", exportOpts.StackName)
+	}
+
+	summary, err := api.GetTemplateSummaryWithContext(c, &cloudformation.GetTemplateSummaryInput{
+		StackName: aws.String(exportOpts.StackName),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "get template summary of stack %s", exportOpts.StackName)
+	}
+
+	params, noEcho := exportParameters(
+		stacks.Stacks[0].Parameters, summary.Parameters, exportOpts.NonDefault)
+
+	data, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal parameters")
+	}
+
+	data = append(data, '\n')
+
+	if err := writeOutputFile(exportOpts.OutputFile, data); err != nil {
+		return err
+	}
+
+	if len(noEcho) > 0 {
+		pprint.Warningf(
+			color.Error,
+			"NoEcho parameters must be filled in by hand: %s",
+			strings.Join(noEcho, ", "))
+	}
+
+	return nil
+}
+
+// exportParameters maps the parameters of a stack to their values. The
+// values of NoEcho parameters are replaced by NoEchoPlaceholder, and their
+// keys are returned in order. If nonDefault is set, parameters whose value
+// equals the template default are omitted. Values are escaped, as manifests
+// template the parameter files they refer to.
+func exportParameters(
+	params []*cloudformation.Parameter,
+	declarations []*cloudformation.ParameterDeclaration,
+	nonDefault bool,
+) (result map[string]string, noEcho []string) {
+	declared := make(map[string]*cloudformation.ParameterDeclaration)
+	for _, decl := range declarations {
+		declared[aws.StringValue(decl.ParameterKey)] = decl
+	}
+
+	result = make(map[string]string)
+	for _, param := range params {
+		key := aws.StringValue(param.ParameterKey)
+		value := aws.StringValue(param.ParameterValue)
+		decl := declared[key]
+
+		if decl != nil && aws.BoolValue(decl.NoEcho) {
+			result[key] = NoEchoPlaceholder
+			noEcho = append(noEcho, key)
+			continue
+		}
+
+		if nonDefault && decl != nil && decl.DefaultValue != nil && *decl.DefaultValue == value {
+			continue
+		}
+
+		result[key] = manifest.EscapeTemplate(value)
+	}
+
+	sort.Strings(noEcho)
+	return result, noEcho
+}
+
+// ParamsInit writes a parameter file with every parameter declared by a
+// template.
+func ParamsInit(c context.Context, globalOpts GlobalOptions, initOpts ParamsInitOptions) error {
+	if isStdout(initOpts.OutputFile) {
+		if err := requireStdout(globalOpts, "params init"); err != nil {
+			return err
+		}
+	}
+
+	body, err := ioutil.ReadFile(initOpts.TemplateFile)
+	if err != nil {
+		return errors.Wrapf(err, "read template: %s", initOpts.TemplateFile)
+	}
+
+	template, err := cftemplate.Parse(body)
+	if err != nil {
+		return errors.Wrap(err, initOpts.TemplateFile)
+	}
+
+	data, err := parameterSkeleton(template)
+	if err != nil {
+		return err
+	}
+
+	return writeOutputFile(initOpts.OutputFile, data)
+}
+
+// parameterSkeleton renders a YAML parameter map with an entry for every
+// parameter of the template, prefilled with its default. Each entry is
+// preceded by comments describing the parameter.
+func parameterSkeleton(template *cftemplate.Template) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for i, p := range template.Parameters {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(p.Description), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&buf, "# %s\n", line)
+			}
+		}
+
+		fmt.Fprintf(&buf, "# Type: %s\n", orDash(p.Type))
+
+		if len(p.AllowedValues) > 0 {
+			fmt.Fprintf(&buf, "# AllowedValues: %s\n", strings.Join(p.AllowedValues, ", "))
+		}
+
+		if p.NoEcho {
+			fmt.Fprintf(&buf, "# NoEcho: true\n")
+		}
+
+		value := ""
+		if p.Default != nil {
+			value = *p.Default
+		} else {
+			fmt.Fprintf(&buf, "# Required: no default.\n")
+		}
+
+		entry, err := yaml.Marshal(map[string]string{p.Name: value})
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal parameter %s", p.Name)
+		}
+
+		buf.Write(entry)
+	}
+
+	// An empty file is not a valid parameter file.
+	if len(template.Parameters) == 0 {
+		buf.WriteString("# The template declares no parameters.\n{}\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isStdout reports whether an output file path means standard output.
+func isStdout(path string) bool {
+	return path == "" || path == "-"
+}
+
+// writeOutputFile writes data to path, or to standard output if path is
+// empty or "-".
+func writeOutputFile(path string, data []byte) error {
+	if isStdout(path) {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return errors.Wrapf(err, "write %s", path)
+	}
+
+	return nil
+}
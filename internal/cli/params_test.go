@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/manifest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportParameters(t *testing.T) {
+	params := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("Env"), ParameterValue: aws.String("live")},
+		{ParameterKey: aws.String("Size"), ParameterValue: aws.String("small")},
+		{ParameterKey: aws.String("Password"), ParameterValue: aws.String("****")},
+		{ParameterKey: aws.String("Removed"), ParameterValue: aws.String("x")},
+	}
+
+	declarations := []*cloudformation.ParameterDeclaration{
+		{ParameterKey: aws.String("Env")},
+		{ParameterKey: aws.String("Size"), DefaultValue: aws.String("small")},
+		{ParameterKey: aws.String("Password"), NoEcho: aws.Bool(true)},
+	}
+
+	result, noEcho := exportParameters(params, declarations, false)
+	assert.Equal(t, map[string]string{
+		"Env":      "live",
+		"Size":     "small",
+		"Password": NoEchoPlaceholder,
+		"Removed":  "x",
+	}, result)
+	assert.Equal(t, []string{"Password"}, noEcho)
+
+	result, _ = exportParameters(params, declarations, true)
+	assert.Equal(t, map[string]string{
+		"Env":      "live",
+		"Password": NoEchoPlaceholder,
+		"Removed":  "x",
+	}, result)
+
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	read, err := manifest.ReadParameters(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, result, read)
+}
+
+func TestParameterSkeleton(t *testing.T) {
+	template, err := cftemplate.Parse([]byte(`
+Parameters:
+  Environment:
+    Type: String
+    Description: Deployment environment.
+    AllowedValues: [live, test]
+  Password:
+    Type: String
+    NoEcho: true
+  Subnets:
+    Type: CommaDelimitedList
+    Default: [a, b]
+  Port:
+    Type: Number
+    Default: 80
+`))
+	require.NoError(t, err)
+
+	data, err := parameterSkeleton(template)
+	require.NoError(t, err)
+	assert.Equal(t, `# Deployment environment.
+# Type: String
+# AllowedValues: live, test
+# Required: no default.
+Environment: ""
+
+# Type: String
+# NoEcho: true
+# Required: no default.
+Password: ""
+
+# Type: CommaDelimitedList
+Subnets: a,b
+
+# Type: Number
+Port: "80"
+`, string(data))
+
+	params, err := manifest.ReadParameters(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Environment": "",
+		"Password":    "",
+		"Subnets":     "a,b",
+		"Port":        "80",
+	}, params)
+}
+
+func TestParameterSkeleton_NoParameters(t *testing.T) {
+	template, err := cftemplate.Parse([]byte(`
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+`))
+	require.NoError(t, err)
+
+	data, err := parameterSkeleton(template)
+	require.NoError(t, err)
+	assert.Equal(t, "# The template declares no parameters.\n{}\n", string(data))
+
+	params, err := manifest.ReadParameters(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Empty(t, params)
+}
+
+func TestExportParameters_RoundTrip(t *testing.T) {
+	params := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("Password"), ParameterValue: aws.String("{{resolve:ssm-secure:pw}}")},
+		{ParameterKey: aws.String("Size"), ParameterValue: aws.String("{{ small }}")},
+	}
+
+	result, _ := exportParameters(params, nil, false)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "cftool-params")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	paramsPath := filepath.Join(dir, "params.json")
+	templatePath := filepath.Join(dir, "template.yml")
+	require.NoError(t, ioutil.WriteFile(paramsPath, data, 0644))
+	require.NoError(t, ioutil.WriteFile(templatePath, []byte("Resources: {}\n"), 0644))
+
+	m, err := manifest.Read(strings.NewReader(fmt.Sprintf(`Version: "1.2"
+Tenants:
+  - Label: live
+Stacks:
+  - Label: app
+    Default:
+      Template: %q
+      Parameters:
+        - File: %q
+    Targets:
+      - Tenant: live
+`, templatePath, paramsPath)))
+	require.NoError(t, err)
+
+	d, err := m.FindDeployment("live", "app")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Password": "{{resolve:ssm-secure:pw}}",
+		"Size":     "{{ small }}",
+	}, d.Parameters)
+}
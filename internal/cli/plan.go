@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/internal"
+	"github.com/tetratom/cftool/pkg/pprint"
+)
+
+// Plan creates change sets for the selected deployments without executing
+// them, and optionally writes a Markdown report of them.
+func Plan(c context.Context, globalOpts GlobalOptions, planOpts PlanOptions) error {
+	if planOpts.ReportFile == "-" {
+		if err := requireStdout(globalOpts, "plan --report -"); err != nil {
+			return err
+		}
+	}
+
+	stsapi, err := globalOpts.AWS.STSClient()
+	if err != nil {
+		return err
+	}
+
+	manifest, manifestPath, err := loadManifest(planOpts.ManifestFile)
+	if err != nil {
+		return err
+	}
+
+	pprint.Field(color.Output, "Manifest", manifestPath)
+
+	pol, err := loadPolicy(manifest)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := manifest.FindDeployments(planOpts.Tenants, planOpts.Stacks)
+	if err != nil {
+		return err
+	}
+
+	deployments, err = narrowRegion(deployments, globalOpts.AWS.Region)
+	if err != nil {
+		return err
+	}
+
+	var report bytes.Buffer
+
+	for i, deployment := range deployments {
+		if i > 0 {
+			fmt.Fprint(color.Output, "\n")
+			fmt.Fprint(&report, "\n")
+		}
+
+		pprint.Field(color.Output, "Tenant", deployment.TenantLabel)
+		pprint.Field(color.Output, "Stack", deployment.StackLabel)
+
+		api, err := globalOpts.AWS.CloudFormationClient(deployment.Region)
+		if err != nil {
+			return err
+		}
+
+		deployer := internal.NewDeployer(api, deployment)
+		deployer.ShowDiff = planOpts.ShowDiff
+		deployer.Events = globalOpts.events
+		deployer.Policy = pol
+
+		id, err := deployer.Whoami(color.Output, stsapi, getRegion(api))
+		if err != nil {
+			return err
+		}
+
+		if deployment.AccountId != "" && deployment.AccountId != *id.Account {
+			return errors.Errorf(
+				"tenant %s expects account %s, but the current account is %s",
+				deployment.TenantLabel, deployment.AccountId, *id.Account)
+		}
+
+		chset, err := deployer.Plan(color.Output)
+		if err != nil {
+			return errors.Wrapf(err, "plan stack: %s", deployment.StackName)
+		}
+
+		if chset == nil {
+			fmt.Fprintf(&report, "### Stack `%s`\n\nNo changes.\n", deployment.StackName)
+		} else {
+			pprint.ChangeSetMarkdown(&report, chset)
+		}
+	}
+
+	if planOpts.ReportFile != "" {
+		return writeOutputFile(planOpts.ReportFile, report.Bytes())
+	}
+
+	return nil
+}
+
+// Report writes a Markdown report of an existing change set.
+func Report(c context.Context, globalOpts GlobalOptions, reportOpts ReportOptions) error {
+	if isStdout(reportOpts.OutputFile) {
+		if err := requireStdout(globalOpts, "report"); err != nil {
+			return err
+		}
+	}
+
+	api, err := globalOpts.AWS.CloudFormationClient("")
+	if err != nil {
+		return err
+	}
+
+	input := &cf.DescribeChangeSetInput{
+		ChangeSetName: aws.String(reportOpts.ChangeSetName),
+	}
+
+	if reportOpts.StackName != "" {
+		input.StackName = aws.String(reportOpts.StackName)
+	}
+
+	chset, err := internal.DescribeChangeSet(c, api, input)
+	if err != nil {
+		return errors.Wrapf(err, "describe change set %s", reportOpts.ChangeSetName)
+	}
+
+	var report bytes.Buffer
+	pprint.ChangeSetMarkdown(&report, chset)
+
+	return writeOutputFile(reportOpts.OutputFile, report.Bytes())
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"os"
+	"strings"
+)
+
+// renderedDeployment is a deployment as shown by the render subcommand. The
+// template body is replaced by its digest.
+type renderedDeployment struct {
+	TenantLabel      string
+	StackLabel       string
+	Protected        bool
+	Constants        map[string]string
+	Tags             map[string]string
+	AccountId        string
+	Region           string
+	StackName        string
+	TemplatePath     string
+	TemplateSHA256   string
+	Parameters       map[string]string
+	ParameterSources map[string]string
+	StackPolicy      string            `json:",omitempty"`
+	StackTags        map[string]string `json:",omitempty"`
+}
+
+func newRenderedDeployment(d *cftool.Deployment) renderedDeployment {
+	digest := sha256.Sum256(d.TemplateBody)
+
+	return renderedDeployment{
+		TenantLabel:      d.TenantLabel,
+		StackLabel:       d.StackLabel,
+		Protected:        d.Protected,
+		Constants:        d.Constants,
+		Tags:             d.Tags,
+		AccountId:        d.AccountId,
+		Region:           d.Region,
+		StackName:        d.StackName,
+		TemplatePath:     d.TemplatePath,
+		TemplateSHA256:   hex.EncodeToString(digest[:]),
+		Parameters:       d.Parameters,
+		ParameterSources: d.ParameterSources,
+		StackPolicy:      d.StackPolicy,
+		StackTags:        d.StackTags,
+	}
+}
+
+// Render prints a fully resolved deployment. It does not use AWS.
+func Render(c context.Context, globalOpts GlobalOptions, renderOpts RenderOptions) error {
+	if err := requireStdout(globalOpts, "render"); err != nil {
+		return err
+	}
+
+	manifest, _, err := loadManifest(renderOpts.ManifestFile)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := manifest.FindDeployments(
+		[]string{renderOpts.Tenant}, []string{renderOpts.Stack})
+	if err != nil {
+		return err
+	}
+
+	deployments, err = narrowRegion(deployments, globalOpts.AWS.Region)
+	if err != nil {
+		return err
+	}
+
+	deployment, err := singleDeployment(deployments, renderOpts.Tenant, renderOpts.Stack)
+	if err != nil {
+		return err
+	}
+
+	return writeStructured(os.Stdout, renderOpts.Format, newRenderedDeployment(deployment))
+}
+
+// singleDeployment returns the only deployment, or explains why there is
+// more than one: the tenant or stack matched several labels, or the stack
+// deploys to several regions.
+func singleDeployment(deployments []*cftool.Deployment, tenant, stack string) (*cftool.Deployment, error) {
+	if len(deployments) == 1 {
+		return deployments[0], nil
+	}
+
+	var labels, regions []string
+	seen := make(map[string]bool)
+	for _, d := range deployments {
+		label := d.TenantLabel + "/" + d.StackLabel
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+
+		regions = append(regions, d.Region)
+	}
+
+	if len(labels) > 1 {
+		return nil, errors.Errorf(
+			"tenant %s stack %s matches several deployments (%s); select exactly one tenant and stack",
+			tenant, stack, strings.Join(labels, ", "))
+	}
+
+	return nil, errors.Errorf(
+		"tenant %s stack %s deploys to several regions (%s); select one with --region",
+		tenant, stack, strings.Join(regions, ", "))
+}
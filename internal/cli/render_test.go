@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"testing"
+)
+
+func TestSingleDeployment(t *testing.T) {
+	one := &cftool.Deployment{TenantLabel: "live", StackLabel: "network", Region: "eu-west-1"}
+
+	d, err := singleDeployment([]*cftool.Deployment{one}, "live", "network")
+	require.NoError(t, err)
+	require.Equal(t, one, d)
+
+	_, err = singleDeployment([]*cftool.Deployment{
+		one,
+		{TenantLabel: "live", StackLabel: "network", Region: "us-east-1"},
+	}, "live", "network")
+	require.EqualError(
+		t, err,
+		"tenant live stack network deploys to several regions (eu-west-1, us-east-1); select one with --region")
+
+	_, err = singleDeployment([]*cftool.Deployment{
+		one,
+		{TenantLabel: "live", StackLabel: "network", Region: "us-east-1"},
+		{TenantLabel: "test", StackLabel: "network", Region: "eu-west-1"},
+	}, "*", "network")
+	require.EqualError(
+		t, err,
+		"tenant * stack network matches several deployments (live/network, test/network); select exactly one tenant and stack")
+}
@@ -29,7 +29,7 @@ func Update(c context.Context, globalOpts GlobalOptions, updateOpts UpdateOption
 		return
 	}
 
-	parameters, err := parseParameters(updateOpts)
+	params, err := parseParameters(updateOpts)
 	if err != nil {
 		return
 	}
@@ -42,14 +42,19 @@ func Update(c context.Context, globalOpts GlobalOptions, updateOpts UpdateOption
 	deployment := cftool.Deployment{
 		AccountId:    "",
 		Region:       "",
+		TemplatePath: updateOpts.TemplateFile,
 		TemplateBody: templateBody,
-		Parameters:   parameters,
+		Parameters:   params.Parameters,
+		StackTags:    params.Tags,
+		StackPolicy:  params.StackPolicy,
 		StackName:    string(stackName), // todo: type conversion
 		Protected:    !updateOpts.Yes,
 	}
 
 	deployer := internal.NewDeployer(api, &deployment)
 	deployer.ShowDiff = updateOpts.ShowDiff
+	deployer.Events = globalOpts.events
+	deployer.Prompter = globalOpts.prompter
 
 	stsapi, err := globalOpts.AWS.STSClient()
 	if err != nil {
@@ -89,28 +94,37 @@ func deriveStackName(opts UpdateOptions) (cftool.StackName, error) {
 	return "", errors.New("unable to derive stack name")
 }
 
-func parseParameters(update UpdateOptions) (cftool.Parameters, error) {
-	files := update.ParameterFiles
-	params := update.Parameters
-	result := make(map[string]string)
-
-	for _, path := range files {
-		paramsFromFile, err := manifest.ReadParametersFromFile(path)
+// parseParameters merges the parameter files and explicit parameters, in
+// that order. Tags and stack policies from template configurations are
+// included.
+func parseParameters(update UpdateOptions) (*manifest.ParameterFile, error) {
+	result := &manifest.ParameterFile{
+		Parameters: make(map[string]string),
+		Tags:       make(map[string]string),
+	}
 
+	for _, path := range update.ParameterFiles {
+		file, err := manifest.ReadParameterFileFromFile(path)
 		if err != nil {
 			return nil, err
 		}
 
-		for k, v := range paramsFromFile {
-			result[k] = v
+		for k, v := range file.Parameters {
+			result.Parameters[k] = v
 		}
-	}
 
-	if len(update.Parameters) > 0 {
-		for _, param := range params {
-			k, v := parseParameterString(param)
-			result[k] = v
+		for k, v := range file.Tags {
+			result.Tags[k] = v
 		}
+
+		if file.StackPolicy != "" {
+			result.StackPolicy = file.StackPolicy
+		}
+	}
+
+	for _, param := range update.Parameters {
+		k, v := parseParameterString(param)
+		result.Parameters[k] = v
 	}
 
 	return result, nil
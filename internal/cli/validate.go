@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/pprint"
+)
+
+// Validate checks the whole manifest without deploying it. Every problem is
+// reported before an error is returned.
+func Validate(c context.Context, globalOpts GlobalOptions, validateOpts ValidateOptions) error {
+	manifest, manifestPath, err := loadManifest(validateOpts.ManifestFile)
+	if err != nil {
+		return err
+	}
+
+	pprint.Field(color.Output, "Manifest", manifestPath)
+
+	problems, deployments := manifest.Validate()
+	fmt.Fprintf(color.Output, "\n")
+
+	for _, problem := range problems {
+		pprint.Errorf(color.Output, "%s", problem)
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf(
+			"manifest has %d problem(s) across %d deployment(s)",
+			len(problems), deployments)
+	}
+
+	fmt.Fprintf(color.Output, "Manifest is valid: %d deployment(s) checked.\n", deployments)
+	return nil
+}
@@ -11,15 +11,21 @@ import (
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/pmezard/go-difflib/difflib"
+	"github.com/tetratom/cftool/internal/events"
+	"github.com/tetratom/cftool/pkg/cftemplate"
 	"github.com/tetratom/cftool/pkg/cftool"
+	"github.com/tetratom/cftool/pkg/policy"
 	"github.com/tetratom/cftool/pkg/pprint"
 	"io"
+	"sort"
 	"strings"
 	"time"
 )
 
 var ErrAbortedByUser = errors.New("aborted by user")
 
+var ErrDeniedByPolicy = errors.New("change set denied by policy")
+
 type StackStatus string
 
 func (status StackStatus) IsComplete() bool {
@@ -34,11 +40,26 @@ func (status StackStatus) IsTerminal() bool {
 	return status.IsComplete() || status.IsFailed()
 }
 
+func (status StackStatus) IsRollback() bool {
+	return strings.Contains(string(status), "ROLLBACK")
+}
+
 type Deployer struct {
 	*cftool.Deployment
 	client        cloudformationiface.CloudFormationAPI
 	ChangeSetName string
 	ShowDiff      bool
+
+	// Events receives a machine-readable account of the deployment. It may
+	// be nil.
+	Events *events.Stream
+
+	// Policy is evaluated against the change set before it is executed. It
+	// may be nil.
+	Policy *policy.Policy
+
+	// Prompter asks for confirmations. If nil, they are asked interactively.
+	Prompter *Prompter
 }
 
 func NewDeployer(api cloudformationiface.CloudFormationAPI, d *cftool.Deployment) *Deployer {
@@ -57,15 +78,20 @@ func (d *Deployer) Deploy(c context.Context, w io.Writer) error {
 	}
 
 	if !exists {
-		if !pprint.Promptf(w, "\nStack %s does not exist. Create?", d.StackName) {
+		ok, err := d.Prompter.Confirm(
+			w, ApproveCreate, "\nStack %s does not exist. Create?", d.StackName)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
 			return ErrAbortedByUser
 		}
 	}
 
 	if exists && d.ShowDiff {
-		err := d.TemplateDiff(w)
-		if err != nil {
-			return errors.Wrap(err, "template diff")
+		if _, err := d.Diff(w); err != nil {
+			return errors.Wrap(err, "diff")
 		}
 	}
 
@@ -81,10 +107,38 @@ func (d *Deployer) Deploy(c context.Context, w io.Writer) error {
 
 	if nochange {
 		fmt.Fprintf(w, "\nNo change.\n")
+		d.Events.Emit(events.Event{
+			Type:      events.TypeStatus,
+			StackName: d.StackName,
+			Status:    events.StatusNoChange,
+		})
+
+		if err := d.setStackPolicy(w); err != nil {
+			return err
+		}
 	} else {
 		pprint.ChangeSet(w, chset)
+		d.Events.Emit(events.Event{
+			Type:      events.TypeChangeSet,
+			StackName: d.StackName,
+			ChangeSet: chset,
+		})
 
-		if d.Protected && !pprint.Promptf(w, "\nExecute change set?") {
+		findings := d.Policy.Evaluate(chset)
+		pprint.PolicyFindings(w, findings)
+
+		verdict := policy.Overall(findings)
+		if verdict == policy.Deny {
+			if err := d.deleteChangeSet(w, chset, exists); err != nil {
+				return err
+			}
+
+			return ErrDeniedByPolicy
+		}
+
+		if ok, err := d.confirmExecute(w, chset, exists, verdict); err != nil {
+			return err
+		} else if !ok {
 			return ErrAbortedByUser
 		}
 
@@ -92,39 +146,75 @@ func (d *Deployer) Deploy(c context.Context, w io.Writer) error {
 			return errors.New("expected non-nil chset")
 		}
 
-		since := time.Now()
+		watermark, err := d.latestEventId()
+		if err != nil {
+			return errors.Wrap(err, "get stack events")
+		}
 
+		token := clientRequestToken()
 		_, err = d.client.ExecuteChangeSet(
 			&cf.ExecuteChangeSetInput{
-				StackName:     chset.StackName,
-				ChangeSetName: chset.ChangeSetName,
+				StackName:          chset.StackName,
+				ChangeSetName:      chset.ChangeSetName,
+				ClientRequestToken: aws.String(token),
 			})
 		if err != nil {
 			return errors.Wrap(err, "execute change set")
 		}
 
-		stack, err := d.monitorStackUpdate(w, since)
+		stack, err := d.monitorStackUpdate(w, token, watermark)
 		if err != nil {
 			return errors.Wrap(err, "monitor stack update")
 		}
 
 		status := StackStatus(*stack.StackStatus)
+		d.Events.Emit(events.Event{
+			Type:      events.TypeStatus,
+			StackName: d.StackName,
+			Status:    string(status),
+		})
+
+		if !status.IsRollback() {
+			if err := d.setStackPolicy(w); err != nil {
+				return err
+			}
+		}
+
 		if !exists && status == cf.StackStatusRollbackComplete {
-			if pprint.Promptf(w, "\nStack failed creation, and must be deleted. Continue?") {
-				_, err := d.client.DeleteStack(&cf.DeleteStackInput{
-					StackName: chset.StackName,
+			ok, err := d.Prompter.Confirm(
+				w, ApproveDeleteFailed, "\nStack failed creation, and must be deleted. Continue?")
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				watermark, err := d.latestEventId()
+				if err != nil {
+					return errors.Wrap(err, "get stack events")
+				}
+
+				token := clientRequestToken()
+				_, err = d.client.DeleteStack(&cf.DeleteStackInput{
+					StackName:          chset.StackName,
+					ClientRequestToken: aws.String(token),
 				})
 
 				if err != nil {
 					return errors.Wrap(err, "delete failed stack")
 				}
 
-				_, err = d.monitorStackUpdate(w, time.Now())
+				stack, err = d.monitorStackUpdate(w, token, watermark)
 
 				if err != nil {
 					return errors.Wrap(err, "monitor stack delete")
 				}
 
+				d.Events.Emit(events.Event{
+					Type:      events.TypeStatus,
+					StackName: d.StackName,
+					Status:    *stack.StackStatus,
+				})
+
 				return nil
 			}
 		}
@@ -141,11 +231,106 @@ func (d *Deployer) Deploy(c context.Context, w io.Writer) error {
 		}
 
 		pprint.StackOutput(w, output)
+		d.Events.Emit(events.Event{
+			Type:        events.TypeStackOutput,
+			StackName:   d.StackName,
+			StackOutput: output,
+		})
 	}
 
 	return nil
 }
 
+// confirmExecute asks whether to execute a change set that needs
+// confirmation, because the stack is protected or because a policy rule
+// requires it. An interactive user reviews the change set. Otherwise, each
+// reason must have been approved on its own.
+func (d *Deployer) confirmExecute(
+	w io.Writer,
+	chset *cf.DescribeChangeSetOutput,
+	exists bool,
+	verdict policy.Verdict,
+) (bool, error) {
+	var approvals []Approval
+	if d.Protected {
+		approvals = append(approvals, ApproveExecute)
+	}
+
+	if verdict == policy.RequireConfirmation {
+		approvals = append(approvals, ApprovePolicy)
+	}
+
+	if len(approvals) == 0 {
+		return true, nil
+	}
+
+	if d.Prompter.Interactive() {
+		return d.review(w, chset, exists)
+	}
+
+	for _, approval := range approvals {
+		ok, err := d.Prompter.Confirm(w, approval, "\nExecute change set?")
+		if err != nil || !ok {
+			return ok, err
+		}
+	}
+
+	return true, nil
+}
+
+// Plan creates a change set for the deployment and prints it, without
+// executing it. The change set is left for review. If there are no changes,
+// nil is returned. If the stack does not exist, CloudFormation creates it in
+// the REVIEW_IN_PROGRESS state.
+func (d *Deployer) Plan(w io.Writer) (*cf.DescribeChangeSetOutput, error) {
+	pprint.Field(w, "StackName", d.StackName)
+
+	exists, err := d.stackExists()
+	if err != nil {
+		return nil, errors.Wrapf(err, "describe stack %s", d.StackName)
+	}
+
+	if !exists {
+		fmt.Fprintf(w, "\nStack %s does not exist, and will be created.\n", d.StackName)
+	}
+
+	if exists && d.ShowDiff {
+		if _, err := d.Diff(w); err != nil {
+			return nil, errors.Wrap(err, "diff")
+		}
+	}
+
+	chset, err := d.createChangeSet(!exists)
+	if err != nil {
+		if strings.Contains(err.Error(), "The submitted information didn't contain changes") {
+			fmt.Fprintf(w, "\nNo change.\n")
+			d.Events.Emit(events.Event{
+				Type:      events.TypeStatus,
+				StackName: d.StackName,
+				Status:    events.StatusNoChange,
+			})
+
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "create change set")
+	}
+
+	pprint.ChangeSet(w, chset)
+	d.Events.Emit(events.Event{
+		Type:      events.TypeChangeSet,
+		StackName: d.StackName,
+		ChangeSet: chset,
+	})
+
+	pprint.PolicyFindings(w, d.Policy.Evaluate(chset))
+
+	fmt.Fprintf(w, "\n")
+	pprint.Field(w, "ChangeSet", d.ChangeSetName)
+
+	return chset, nil
+}
+
 func (d *Deployer) describeStack() (*cf.Stack, error) {
 	stacks, err := d.client.DescribeStacks(
 		&cf.DescribeStacksInput{StackName: aws.String(d.StackName)})
@@ -204,6 +389,13 @@ func (d *Deployer) createChangeSet(create bool) (*cf.DescribeChangeSetOutput, er
 		index += 1
 	}
 
+	for _, key := range sortedKeys(d.StackTags) {
+		input.Tags = append(input.Tags, &cf.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(d.StackTags[key]),
+		})
+	}
+
 	_, err := d.client.CreateChangeSet(&input)
 	if err != nil {
 		return nil, err
@@ -216,7 +408,9 @@ func (d *Deployer) createChangeSet(create bool) (*cf.DescribeChangeSetOutput, er
 		// at the start of the loop.
 		time.Sleep(2 * time.Second)
 
-		chset, err = d.client.DescribeChangeSet(
+		chset, err = DescribeChangeSet(
+			aws.BackgroundContext(),
+			d.client,
 			&cf.DescribeChangeSetInput{
 				StackName:     aws.String(d.StackName),
 				ChangeSetName: aws.String(d.ChangeSetName),
@@ -241,25 +435,149 @@ func (d *Deployer) createChangeSet(create bool) (*cf.DescribeChangeSetOutput, er
 	return chset, nil
 }
 
-func (d *Deployer) getStackEvents(since time.Time, until time.Time) ([]*cf.StackEvent, error) {
+// deleteChangeSet deletes a change set that will not be executed. If the
+// stack did not exist before, the empty stack that was created in the
+// REVIEW_IN_PROGRESS state for the change set is deleted as well.
+func (d *Deployer) deleteChangeSet(w io.Writer, chset *cf.DescribeChangeSetOutput, exists bool) error {
+	_, err := d.client.DeleteChangeSet(&cf.DeleteChangeSetInput{
+		StackName:     chset.StackName,
+		ChangeSetName: chset.ChangeSetName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "delete change set")
+	}
+
+	fmt.Fprintf(w, "\nChange set %s deleted.\n", aws.StringValue(chset.ChangeSetName))
+
+	if exists {
+		return nil
+	}
+
+	_, err = d.client.DeleteStack(&cf.DeleteStackInput{
+		StackName:          chset.StackName,
+		ClientRequestToken: aws.String(clientRequestToken()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "delete stack in review")
+	}
+
+	fmt.Fprintf(w, "Stack %s deleted.\n", aws.StringValue(chset.StackName))
+	return nil
+}
+
+// DescribeChangeSet describes a change set, including the changes of every
+// page.
+func DescribeChangeSet(
+	c context.Context,
+	api cloudformationiface.CloudFormationAPI,
+	input *cf.DescribeChangeSetInput,
+) (*cf.DescribeChangeSetOutput, error) {
+	var result *cf.DescribeChangeSetOutput
+
+	for {
+		out, err := api.DescribeChangeSetWithContext(c, input)
+		if err != nil {
+			return nil, err
+		}
+
+		if result == nil {
+			result = out
+		} else {
+			result.Changes = append(result.Changes, out.Changes...)
+		}
+
+		if aws.StringValue(out.NextToken) == "" {
+			break
+		}
+
+		next := *input
+		next.NextToken = out.NextToken
+		input = &next
+	}
+
+	result.NextToken = nil
+	return result, nil
+}
+
+func (d *Deployer) setStackPolicy(w io.Writer) error {
+	if d.StackPolicy == "" {
+		return nil
+	}
+
+	_, err := d.client.SetStackPolicy(&cf.SetStackPolicyInput{
+		StackName:       aws.String(d.StackName),
+		StackPolicyBody: aws.String(d.StackPolicy),
+	})
+	if err != nil {
+		return errors.Wrap(err, "set stack policy")
+	}
+
+	fmt.Fprintf(w, "\nStack policy applied.\n")
+	return nil
+}
+
+// clientRequestToken returns a unique token for a stack operation. Every
+// event of the operation carries it.
+func clientRequestToken() string {
+	return "cftool-" + uuid.New().String()
+}
+
+// latestEventId returns the ID of the newest stack event, or "" if there are
+// none. It is the watermark from which to look for the events of a new
+// operation.
+func (d *Deployer) latestEventId() (string, error) {
 	out, err := d.client.DescribeStackEvents(
 		&cf.DescribeStackEventsInput{
 			StackName: aws.String(d.StackName),
 		})
 	if err != nil {
-		return nil, errors.Wrap(err, "describe stack events")
+		return "", errors.Wrap(err, "describe stack events")
 	}
 
-	result := make([]*cf.StackEvent, 0, len(out.StackEvents))
-	for _, event := range out.StackEvents {
-		if (event.Timestamp.After(since) || event.Timestamp.Equal(since)) &&
-			event.Timestamp.Before(until) {
+	if len(out.StackEvents) == 0 {
+		return "", nil
+	}
 
-			result = append(result, event)
-		}
+	return aws.StringValue(out.StackEvents[0].EventId), nil
+}
+
+// getStackEvents returns the events of the operation with the given client
+// request token that are newer than the event with the ID watermark, newest
+// first. Events are listed newest first, so pages are read until the
+// watermark is found. The ID of the newest event of any operation is
+// returned as the next watermark. Timestamps are never compared, so the
+// local clock does not matter.
+func (d *Deployer) getStackEvents(token string, watermark string) ([]*cf.StackEvent, string, error) {
+	var result []*cf.StackEvent
+	next := watermark
+
+	err := d.client.DescribeStackEventsPages(
+		&cf.DescribeStackEventsInput{
+			StackName: aws.String(d.StackName),
+		},
+		func(out *cf.DescribeStackEventsOutput, lastPage bool) bool {
+			for _, event := range out.StackEvents {
+				id := aws.StringValue(event.EventId)
+				if id == watermark {
+					return false
+				}
+
+				if next == watermark {
+					next = id
+				}
+
+				if aws.StringValue(event.ClientRequestToken) == token {
+					result = append(result, event)
+				}
+			}
+
+			return true
+		})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "describe stack events")
 	}
 
-	return result, nil
+	return result, next, nil
 }
 
 func (d *Deployer) getStackOutputs() ([]*cf.Output, error) {
@@ -274,9 +592,11 @@ func (d *Deployer) getStackOutputs() ([]*cf.Output, error) {
 	return stack.Stacks[0].Outputs, nil
 }
 
-func (d *Deployer) monitorStackUpdate(w io.Writer, startTime time.Time) (stack *cf.Stack, err error) {
+// monitorStackUpdate waits for the operation with the given client request
+// token to finish, printing its failures. Only events newer than the event
+// with the ID watermark are considered.
+func (d *Deployer) monitorStackUpdate(w io.Writer, token string, watermark string) (stack *cf.Stack, err error) {
 	lastStatus := StackStatus("UNKNOWN")
-	since := startTime
 
 	for i := 0; ; i++ {
 		stack, err = d.describeStack()
@@ -292,14 +612,19 @@ func (d *Deployer) monitorStackUpdate(w io.Writer, startTime time.Time) (stack *
 
 		if status != lastStatus {
 			fmt.Fprintf(w, "\n")
-			t := time.Now()
-			events, err := d.getStackEvents(since, t)
-			since = t
+			var stackEvents []*cf.StackEvent
+			stackEvents, watermark, err = d.getStackEvents(token, watermark)
 			if err != nil {
 				return nil, errors.Wrap(err, "get stack events")
 			}
 
-			for _, event := range events {
+			for _, event := range stackEvents {
+				d.Events.Emit(events.Event{
+					Type:       events.TypeStackEvent,
+					StackName:  d.StackName,
+					StackEvent: event,
+				})
+
 				if strings.HasSuffix(*event.ResourceStatus, "_FAILED") ||
 					strings.HasSuffix(*event.ResourceStatus, "_ROLLBACK_IN_PROGRESS") {
 
@@ -343,20 +668,100 @@ func (d *Deployer) Whoami(w io.Writer, api stsiface.STSAPI, region string) (*sts
 	}
 
 	pprint.Whoami(w, &region, id)
+	d.Events.Emit(events.Event{
+		Type: events.TypeIdentity,
+		Identity: &events.Identity{
+			Account: aws.StringValue(id.Account),
+			Arn:     aws.StringValue(id.Arn),
+			UserId:  aws.StringValue(id.UserId),
+			Region:  region,
+		},
+	})
+
 	return id, nil
 }
 
-func (d *Deployer) TemplateDiff(w io.Writer) error {
+// stackDiff holds the differences between a stack and the deployment.
+type stackDiff struct {
+	// Exists is false if the stack does not exist, in which case there are
+	// no other differences.
+	Exists bool
+
+	Template []cftemplate.Change
+
+	// Textual is set if either template could not be parsed, in which case
+	// Lines holds a unified diff of the templates instead of Template.
+	Textual bool
+	Lines   string
+
+	Parameters []cftool.ValueChange
+	Tags       []cftool.ValueChange
+}
+
+func (sd *stackDiff) Changed() bool {
+	return !sd.Exists ||
+		len(sd.Template) > 0 ||
+		sd.Lines != "" ||
+		len(sd.Parameters) > 0 ||
+		len(sd.Tags) > 0
+}
+
+func (sd *stackDiff) printTemplate(w io.Writer) {
+	if sd.Textual {
+		printLineDiff(w, sd.Lines)
+	} else {
+		pprint.TemplateDiff(w, sd.Template)
+	}
+}
+
+// Diff prints the differences between the stack and the deployment: those
+// of the template, the parameters and the tags. It reports whether there are
+// any. A stack that does not exist differs.
+func (d *Deployer) Diff(w io.Writer) (bool, error) {
 	fmt.Fprintf(w, "\n")
 
-	exists, err := d.stackExists()
+	diff, err := d.diff()
+	if err != nil {
+		return false, err
+	}
 
-	switch {
-	case err != nil:
-		return errors.Wrapf(err, "describe stack %s", d.StackName)
+	if !diff.Exists {
+		fmt.Fprintf(w, "Stack %s does not exist.\n", d.StackName)
+		d.Events.Emit(events.Event{
+			Type:      events.TypeDiff,
+			StackName: d.StackName,
+			Diff:      &events.Diff{Changed: true},
+		})
+
+		return true, nil
+	}
+
+	diff.printTemplate(w)
+	pprint.ValueDiff(w, "Parameter values", diff.Parameters)
+	pprint.ValueDiff(w, "Stack tags", diff.Tags)
+
+	d.Events.Emit(events.Event{
+		Type:      events.TypeDiff,
+		StackName: d.StackName,
+		Diff: &events.Diff{
+			Changed:    diff.Changed(),
+			Template:   diff.Template,
+			Parameters: diff.Parameters,
+			Tags:       diff.Tags,
+		},
+	})
+
+	return diff.Changed(), nil
+}
+
+func (d *Deployer) diff() (*stackDiff, error) {
+	stack, err := d.describeStack()
+	if err != nil {
+		if strings.Contains(err.Error(), "does not exist") {
+			return &stackDiff{}, nil
+		}
 
-	case !exists:
-		return errors.Errorf("stack %s does not exist.", d.StackName)
+		return nil, err
 	}
 
 	out, err := d.client.GetTemplate(&cf.GetTemplateInput{
@@ -364,11 +769,81 @@ func (d *Deployer) TemplateDiff(w io.Writer) error {
 	})
 
 	if err != nil {
-		return errors.Wrap(err, "get template")
+		return nil, errors.Wrap(err, "get template")
+	}
+
+	result := &stackDiff{Exists: true}
+
+	// If either template cannot be parsed, fall back to a textual diff, and
+	// compare parameters as given.
+	local, localErr := cftemplate.Parse(d.TemplateBody)
+	deployed, deployedErr := cftemplate.Parse([]byte(*out.TemplateBody))
+
+	if localErr != nil || deployedErr != nil {
+		result.Textual = true
+		result.Lines, err = d.unifiedDiff(*out.TemplateBody)
+		if err != nil {
+			return nil, err
+		}
+
+		local = &cftemplate.Template{}
+	} else {
+		result.Template = cftemplate.Diff(deployed, local)
+	}
+
+	current := make(map[string]string)
+	masked := make(map[string]bool)
+	for _, p := range stack.Parameters {
+		current[*p.ParameterKey] = aws.StringValue(p.ParameterValue)
+		if aws.StringValue(p.ParameterValue) == cftool.MaskedValue {
+			masked[*p.ParameterKey] = true
+		}
+	}
+
+	for _, p := range local.Parameters {
+		if p.NoEcho {
+			masked[p.Name] = true
+		}
+	}
+
+	result.Parameters = cftool.DiffValues(current, d.effectiveParameters(local), masked)
+
+	// Without stack tags, the change set leaves the tags of the stack as
+	// they are.
+	if len(d.StackTags) > 0 {
+		tags := make(map[string]string)
+		for _, tag := range stack.Tags {
+			tags[*tag.Key] = aws.StringValue(tag.Value)
+		}
+
+		result.Tags = cftool.DiffValues(tags, d.StackTags, nil)
+	}
+
+	return result, nil
+}
+
+// effectiveParameters returns the parameter values that the stack would
+// have after deployment, including template defaults.
+func (d *Deployer) effectiveParameters(t *cftemplate.Template) map[string]string {
+	result := make(map[string]string)
+	for _, p := range t.Parameters {
+		if p.Default != nil {
+			result[p.Name] = *p.Default
+		}
 	}
 
+	for k, v := range d.Parameters {
+		result[k] = v
+	}
+
+	return result
+}
+
+// unifiedDiff returns a textual diff of the templates, for templates that
+// cannot be compared structurally.
+func (d *Deployer) unifiedDiff(deployed string) (string, error) {
 	diff := difflib.UnifiedDiff{
-		A: difflib.SplitLines(*out.TemplateBody),
+		A: difflib.SplitLines(deployed),
 		B: difflib.SplitLines(
 			strings.ReplaceAll(
 				string(d.TemplateBody), "\r", "")),
@@ -379,9 +854,13 @@ func (d *Deployer) TemplateDiff(w io.Writer) error {
 
 	text, err := difflib.GetUnifiedDiffString(diff)
 	if err != nil {
-		return errors.Wrap(err, "unified diff")
+		return "", errors.Wrap(err, "unified diff")
 	}
 
+	return text, nil
+}
+
+func printLineDiff(w io.Writer, text string) {
 	lines := strings.Split(text, "\n")
 
 	for _, line := range lines {
@@ -406,6 +885,14 @@ func (d *Deployer) TemplateDiff(w io.Writer) error {
 
 		fmt.Fprintf(w, "\n")
 	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
 
-	return nil
+	sort.Strings(keys)
+	return keys
 }
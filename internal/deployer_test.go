@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"github.com/tetratom/cftool/pkg/policy"
+	"strconv"
+	"testing"
+)
+
+// pagedAPI serves change sets and stack events in pages.
+type pagedAPI struct {
+	cloudformationiface.CloudFormationAPI
+	changePages [][]*cf.Change
+	eventPages  [][]*cf.StackEvent
+	pagesRead   int
+}
+
+func (api *pagedAPI) DescribeChangeSetWithContext(
+	_ aws.Context,
+	input *cf.DescribeChangeSetInput,
+	_ ...request.Option,
+) (*cf.DescribeChangeSetOutput, error) {
+	page := 0
+	if input.NextToken != nil {
+		page, _ = strconv.Atoi(*input.NextToken)
+	}
+
+	out := &cf.DescribeChangeSetOutput{
+		ChangeSetName: input.ChangeSetName,
+		Status:        aws.String(cf.ChangeSetStatusCreateComplete),
+		Changes:       api.changePages[page],
+	}
+
+	if page+1 < len(api.changePages) {
+		out.NextToken = aws.String(strconv.Itoa(page + 1))
+	}
+
+	return out, nil
+}
+
+func (api *pagedAPI) DescribeStackEventsPages(
+	input *cf.DescribeStackEventsInput,
+	fn func(*cf.DescribeStackEventsOutput, bool) bool,
+) error {
+	for i, page := range api.eventPages {
+		api.pagesRead++
+		if !fn(&cf.DescribeStackEventsOutput{StackEvents: page}, i == len(api.eventPages)-1) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func resourceChange(logicalId string) *cf.Change {
+	return &cf.Change{
+		Type: aws.String(cf.ChangeTypeResource),
+		ResourceChange: &cf.ResourceChange{
+			LogicalResourceId: aws.String(logicalId),
+		},
+	}
+}
+
+func TestDescribeChangeSet(t *testing.T) {
+	api := &pagedAPI{
+		changePages: [][]*cf.Change{
+			{resourceChange("A"), resourceChange("B")},
+			{resourceChange("C")},
+			{resourceChange("D")},
+		},
+	}
+
+	chset, err := DescribeChangeSet(
+		aws.BackgroundContext(),
+		api,
+		&cf.DescribeChangeSetInput{ChangeSetName: aws.String("StackUpdate-1")})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, change := range chset.Changes {
+		ids = append(ids, *change.ResourceChange.LogicalResourceId)
+	}
+
+	assert.Equal(t, []string{"A", "B", "C", "D"}, ids)
+	assert.Nil(t, chset.NextToken)
+	assert.Equal(t, "StackUpdate-1", *chset.ChangeSetName)
+}
+
+func TestDeployer_GetStackEvents(t *testing.T) {
+	event := func(id string, token string) *cf.StackEvent {
+		return &cf.StackEvent{
+			EventId:            aws.String(id),
+			ClientRequestToken: aws.String(token),
+		}
+	}
+
+	// Events are listed newest first. Event 4 is of another operation, and
+	// event 1 has already been seen.
+	api := &pagedAPI{
+		eventPages: [][]*cf.StackEvent{
+			{event("6", "mine"), event("5", "mine")},
+			{event("4", "other"), event("3", "mine")},
+			{event("2", "mine"), event("1", "mine")},
+			{event("0", "mine")},
+		},
+	}
+
+	d := NewDeployer(api, &cftool.Deployment{StackName: "foo"})
+	result, watermark, err := d.getStackEvents("mine", "1")
+	require.NoError(t, err)
+
+	var ids []string
+	for _, event := range result {
+		ids = append(ids, *event.EventId)
+	}
+
+	assert.Equal(t, []string{"6", "5", "3", "2"}, ids)
+	assert.Equal(t, "6", watermark)
+	assert.Equal(t, 3, api.pagesRead)
+
+	api.pagesRead = 0
+	result, watermark, err = d.getStackEvents("mine", "6")
+	require.NoError(t, err)
+	assert.Empty(t, result)
+	assert.Equal(t, "6", watermark)
+	assert.Equal(t, 1, api.pagesRead)
+}
+
+// cleanupAPI records the change sets and stacks that are deleted.
+type cleanupAPI struct {
+	cloudformationiface.CloudFormationAPI
+	deletedChangeSets []string
+	deletedStacks     []string
+}
+
+func (api *cleanupAPI) DeleteChangeSet(input *cf.DeleteChangeSetInput) (*cf.DeleteChangeSetOutput, error) {
+	api.deletedChangeSets = append(api.deletedChangeSets, *input.ChangeSetName)
+	return &cf.DeleteChangeSetOutput{}, nil
+}
+
+func (api *cleanupAPI) DeleteStack(input *cf.DeleteStackInput) (*cf.DeleteStackOutput, error) {
+	api.deletedStacks = append(api.deletedStacks, *input.StackName)
+	return &cf.DeleteStackOutput{}, nil
+}
+
+func TestDeployer_DeleteChangeSet(t *testing.T) {
+	chset := &cf.DescribeChangeSetOutput{
+		StackName:     aws.String("foo"),
+		ChangeSetName: aws.String("StackUpdate-1"),
+	}
+
+	t.Run("existing stack", func(t *testing.T) {
+		api := &cleanupAPI{}
+		d := NewDeployer(api, &cftool.Deployment{StackName: "foo"})
+
+		var w bytes.Buffer
+		require.NoError(t, d.deleteChangeSet(&w, chset, true))
+		assert.Equal(t, []string{"StackUpdate-1"}, api.deletedChangeSets)
+		assert.Empty(t, api.deletedStacks)
+		assert.Equal(t, "\nChange set StackUpdate-1 deleted.\n", w.String())
+	})
+
+	t.Run("new stack", func(t *testing.T) {
+		api := &cleanupAPI{}
+		d := NewDeployer(api, &cftool.Deployment{StackName: "foo"})
+
+		var w bytes.Buffer
+		require.NoError(t, d.deleteChangeSet(&w, chset, false))
+		assert.Equal(t, []string{"StackUpdate-1"}, api.deletedChangeSets)
+		assert.Equal(t, []string{"foo"}, api.deletedStacks)
+		assert.Equal(t, "\nChange set StackUpdate-1 deleted.\nStack foo deleted.\n", w.String())
+	})
+}
+
+func TestDeployer_ConfirmExecute(t *testing.T) {
+	chset := &cf.DescribeChangeSetOutput{
+		StackName:     aws.String("foo"),
+		ChangeSetName: aws.String("StackUpdate-1"),
+	}
+
+	confirm := func(approved []Approval) (bool, error) {
+		d := NewDeployer(&cleanupAPI{}, &cftool.Deployment{StackName: "foo", Protected: true})
+		d.Prompter = &Prompter{NonInteractive: true, Approved: make(map[Approval]bool)}
+		for _, approval := range approved {
+			d.Prompter.Approved[approval] = true
+		}
+
+		var w bytes.Buffer
+		return d.confirmExecute(&w, chset, true, policy.RequireConfirmation)
+	}
+
+	// A protected stack needs execute, and the policy needs policy.
+	_, err := confirm([]Approval{ApprovePolicy})
+	require.Error(t, err)
+	assert.True(t, IsNonInteractive(err))
+	assert.Contains(t, err.Error(), "--approve execute")
+
+	_, err = confirm([]Approval{ApproveExecute})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--approve policy")
+
+	ok, err := confirm([]Approval{ApproveExecute, ApprovePolicy})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
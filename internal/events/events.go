@@ -0,0 +1,89 @@
+// Package events writes a machine-readable account of a cftool run as a
+// stream of newline-delimited JSON objects.
+package events
+
+import (
+	"encoding/json"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	TypeIdentity    = "Identity"
+	TypeDiff        = "Diff"
+	TypeChangeSet   = "ChangeSet"
+	TypeStackEvent  = "StackEvent"
+	TypeStackOutput = "StackOutput"
+	TypeStatus      = "Status"
+	TypeError       = "Error"
+)
+
+// Statuses that are not CloudFormation stack statuses.
+const (
+	StatusNoChange = "NO_CHANGE"
+)
+
+// Event is a single entry in the stream. Type determines which of the other
+// properties are set.
+type Event struct {
+	Type      string
+	Time      time.Time
+	StackName string `json:",omitempty"`
+
+	Identity    *Identity                   `json:",omitempty"`
+	Diff        *Diff                       `json:",omitempty"`
+	ChangeSet   *cf.DescribeChangeSetOutput `json:",omitempty"`
+	StackEvent  *cf.StackEvent              `json:",omitempty"`
+	StackOutput *cf.Output                  `json:",omitempty"`
+
+	// Status is the final status of a stack: a CloudFormation stack status,
+	// or StatusNoChange.
+	Status string `json:",omitempty"`
+
+	// Message is the text of an Error.
+	Message string `json:",omitempty"`
+}
+
+type Identity struct {
+	Account string
+	Arn     string
+	UserId  string
+	Region  string
+}
+
+type Diff struct {
+	Changed    bool
+	Template   []cftemplate.Change
+	Parameters []cftool.ValueChange
+	Tags       []cftool.ValueChange
+}
+
+// Stream writes events. A nil *Stream discards them.
+type Stream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+func NewStream(w io.Writer) *Stream {
+	return &Stream{enc: json.NewEncoder(w), now: time.Now}
+}
+
+// Emit writes an event, setting its time. As with human output, errors
+// writing the event are ignored.
+func (s *Stream) Emit(event Event) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.Time = s.now().UTC()
+	_ = s.enc.Encode(event)
+}
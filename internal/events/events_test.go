@@ -0,0 +1,36 @@
+package events
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	var nilStream *Stream
+	nilStream.Emit(Event{Type: TypeStatus})
+
+	var buf bytes.Buffer
+	s := NewStream(&buf)
+	s.now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	s.Emit(Event{
+		Type:     TypeIdentity,
+		Identity: &Identity{Account: "111111111111", Region: "eu-west-1"},
+	})
+	s.Emit(Event{
+		Type:        TypeStackOutput,
+		StackName:   "mystack",
+		StackOutput: &cf.Output{OutputKey: aws.String("Url"), OutputValue: aws.String("x")},
+	})
+	s.Emit(Event{Type: TypeStatus, StackName: "mystack", Status: StatusNoChange})
+
+	assert.Equal(t,
+		`{"Type":"Identity","Time":"2020-01-02T03:04:05Z","Identity":{"Account":"111111111111","Arn":"","UserId":"","Region":"eu-west-1"}}`+"\n"+
+			`{"Type":"StackOutput","Time":"2020-01-02T03:04:05Z","StackName":"mystack","StackOutput":{"Description":null,"ExportName":null,"OutputKey":"Url","OutputValue":"x"}}`+"\n"+
+			`{"Type":"Status","Time":"2020-01-02T03:04:05Z","StackName":"mystack","Status":"NO_CHANGE"}`+"\n",
+		buf.String())
+}
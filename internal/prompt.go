@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/pprint"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdin is shared by every prompt, so that input read ahead by one is not
+// lost to the next.
+var stdin = bufio.NewReader(os.Stdin)
+
+// ErrNonInteractive is the cause of errors returned when a confirmation is
+// required, but prompting is not possible.
+var ErrNonInteractive = errors.New("confirmation required in non-interactive mode")
+
+// IsNonInteractive reports whether err was caused by ErrNonInteractive,
+// including errors wrapped by the AWS SDK, such as those of the MFA token
+// provider.
+func IsNonInteractive(err error) bool {
+	for err != nil {
+		if err == ErrNonInteractive {
+			return true
+		}
+
+		switch e := err.(type) {
+		case interface{ Cause() error }:
+			err = e.Cause()
+		case awserr.Error:
+			err = e.OrigErr()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// Approval identifies a kind of confirmation, so that it can be approved in
+// advance.
+type Approval string
+
+const (
+	// ApproveCreate allows a stack that does not exist to be created.
+	ApproveCreate Approval = "create"
+
+	// ApproveExecute allows a change set of a protected stack to be
+	// executed.
+	ApproveExecute Approval = "execute"
+
+	// ApprovePolicy allows a change set to be executed despite policy rules
+	// with the require-confirmation verdict.
+	ApprovePolicy Approval = "policy"
+
+	// ApproveDeleteFailed allows a stack that failed creation to be deleted.
+	ApproveDeleteFailed Approval = "delete-failed"
+)
+
+// Approvals lists every kind of approval.
+var Approvals = []Approval{
+	ApproveCreate,
+	ApproveExecute,
+	ApprovePolicy,
+	ApproveDeleteFailed,
+}
+
+// ParseApprovals validates the names of approvals.
+func ParseApprovals(names []string) (map[Approval]bool, error) {
+	known := make(map[Approval]bool)
+	for _, approval := range Approvals {
+		known[approval] = true
+	}
+
+	result := make(map[Approval]bool)
+	for _, name := range names {
+		approval := Approval(name)
+		if !known[approval] {
+			var valid []string
+			for _, approval := range Approvals {
+				valid = append(valid, string(approval))
+			}
+
+			return nil, errors.Errorf(
+				"unknown approval %q (one of: %s)", name, strings.Join(valid, ", "))
+		}
+
+		result[approval] = true
+	}
+
+	return result, nil
+}
+
+// Prompter asks for confirmations. Interactively, it prompts on the terminal.
+// Otherwise, confirmations that were approved in advance succeed, and the
+// rest fail with an error caused by ErrNonInteractive.
+type Prompter struct {
+	NonInteractive bool
+	Approved       map[Approval]bool
+
+	// Input is read for answers. If nil, standard input is read.
+	Input *bufio.Reader
+}
+
+type nonInteractiveError struct {
+	approval Approval
+	question string
+}
+
+func (e *nonInteractiveError) Error() string {
+	return fmt.Sprintf(
+		"%s: %s (pass --approve %s to allow)",
+		ErrNonInteractive, strings.TrimSpace(e.question), e.approval)
+}
+
+func (e *nonInteractiveError) Cause() error {
+	return ErrNonInteractive
+}
+
+// Interactive reports whether the Prompter prompts on the terminal. A nil
+// Prompter does.
+func (p *Prompter) Interactive() bool {
+	return p == nil || !p.NonInteractive
+}
+
+// input returns the reader for answers. A nil Prompter reads standard input.
+func (p *Prompter) input() *bufio.Reader {
+	if p == nil || p.Input == nil {
+		return stdin
+	}
+
+	return p.Input
+}
+
+// Confirm asks the question, and reports whether it was confirmed.
+func (p *Prompter) Confirm(w io.Writer, approval Approval, format string, args ...interface{}) (bool, error) {
+	if p.Interactive() {
+		return pprint.Promptf(w, p.input(), format, args...), nil
+	}
+
+	question := fmt.Sprintf(format, args...)
+
+	if !p.Approved[approval] {
+		return false, &nonInteractiveError{approval: approval, question: question}
+	}
+
+	fmt.Fprintf(w, "%s [approved: %s]\n", question, approval)
+	return true, nil
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseApprovals(t *testing.T) {
+	approved, err := ParseApprovals([]string{"create", "delete-failed"})
+	require.NoError(t, err)
+	assert.Equal(t, map[Approval]bool{
+		ApproveCreate:       true,
+		ApproveDeleteFailed: true,
+	}, approved)
+
+	_, err = ParseApprovals([]string{"everything"})
+	assert.EqualError(
+		t, err,
+		`unknown approval "everything" (one of: create, execute, policy, delete-failed)`)
+}
+
+func TestPrompter_Confirm(t *testing.T) {
+	p := &Prompter{
+		NonInteractive: true,
+		Approved:       map[Approval]bool{ApproveCreate: true},
+	}
+
+	var buf bytes.Buffer
+	ok, err := p.Confirm(&buf, ApproveCreate, "\nStack %s does not exist. Create?", "foo")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "\nStack foo does not exist. Create? [approved: create]\n", buf.String())
+
+	buf.Reset()
+	ok, err = p.Confirm(&buf, ApproveExecute, "\nExecute change set?")
+	assert.False(t, ok)
+	assert.EqualError(
+		t, err,
+		"confirmation required in non-interactive mode: Execute change set? (pass --approve execute to allow)")
+	assert.True(t, IsNonInteractive(err))
+	assert.Empty(t, buf.String())
+}
+
+func TestIsNonInteractive(t *testing.T) {
+	wrapped := errors.Wrap(ErrNonInteractive, "MFA token required")
+	assert.True(t, IsNonInteractive(wrapped))
+	assert.True(t, IsNonInteractive(awserr.New("AssumeRoleTokenProviderNotSetError", "failed", wrapped)))
+	assert.False(t, IsNonInteractive(ErrAbortedByUser))
+	assert.False(t, IsNonInteractive(nil))
+}
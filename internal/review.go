@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/pprint"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const reviewHelp = `  t          show the template diff
+  p          show parameter and tag diffs
+  r ID       show the details of resource ID
+  v          view the full change set in $PAGER
+  s [FILE]   save a Markdown report (default: <change set name>.md)
+  x          execute the change set
+  a          abort, and delete the change set
+  ?          show this help
+`
+
+// review lets the user investigate a change set before deciding whether to
+// execute it. It reports whether the change set should be executed. If the
+// user aborts, the change set is deleted, along with the stack if it is new;
+// if standard input is closed, both are kept.
+func (d *Deployer) review(w io.Writer, chset *cf.DescribeChangeSetOutput, exists bool) (bool, error) {
+	in := d.Prompter.input()
+
+	// The diff is only fetched when needed, and only once.
+	var diff *stackDiff
+	loadDiff := func() (*stackDiff, error) {
+		if diff != nil {
+			return diff, nil
+		}
+
+		result, err := d.diff()
+		if err != nil {
+			return nil, errors.Wrap(err, "diff")
+		}
+
+		diff = result
+		return diff, nil
+	}
+
+	fmt.Fprintf(w, "\nReview the change set before executing it:\n%s", reviewHelp)
+
+	for {
+		fmt.Fprintf(w, "\nExecute change set? [t,p,r,v,s,x,a,?] ")
+
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			fmt.Fprintf(w, "\n")
+			return false, nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		command, args := fields[0], fields[1:]
+
+		switch command {
+		case "t", "p":
+			if !exists {
+				fmt.Fprintf(w, "\nStack %s does not exist. Everything is new.\n", d.StackName)
+				continue
+			}
+
+			sd, err := loadDiff()
+			if err != nil {
+				pprint.Errorf(w, "%v", err)
+				continue
+			}
+
+			fmt.Fprintf(w, "\n")
+
+			if command == "t" {
+				sd.printTemplate(w)
+				continue
+			}
+
+			if len(sd.Parameters) == 0 && len(sd.Tags) == 0 {
+				fmt.Fprintf(w, "No parameter or tag changes.\n")
+			}
+
+			pprint.ValueDiff(w, "Parameter values", sd.Parameters)
+			pprint.ValueDiff(w, "Stack tags", sd.Tags)
+
+		case "r":
+			if len(args) != 1 {
+				fmt.Fprintf(w, "Usage: r LOGICAL-ID\n")
+				continue
+			}
+
+			change := findResourceChange(chset, args[0])
+			if change == nil {
+				fmt.Fprintf(w, "Resource %s is not in the change set.\n", args[0])
+				continue
+			}
+
+			fmt.Fprintf(w, "\n")
+			pprint.ResourceChange(w, change)
+
+			if !exists {
+				continue
+			}
+
+			sd, err := loadDiff()
+			if err != nil {
+				pprint.Errorf(w, "%v", err)
+				continue
+			}
+
+			var changes []cftemplate.Change
+			for _, c := range sd.Template {
+				if c.Section == "Resources" && c.LogicalId == args[0] {
+					changes = append(changes, c)
+				}
+			}
+
+			if len(changes) > 0 {
+				fmt.Fprintf(w, "\n")
+				pprint.TemplateDiff(w, changes)
+			}
+
+		case "v":
+			if err := pageChangeSet(w, chset); err != nil {
+				pprint.Errorf(w, "%v", err)
+			}
+
+		case "s":
+			path := d.ChangeSetName + ".md"
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			var report bytes.Buffer
+			pprint.ChangeSetMarkdown(&report, chset)
+
+			if err := ioutil.WriteFile(path, report.Bytes(), 0644); err != nil {
+				pprint.Errorf(w, "%v", errors.Wrap(err, "write report"))
+				continue
+			}
+
+			fmt.Fprintf(w, "Report written to %s.\n", path)
+
+		case "x":
+			return true, nil
+
+		case "a":
+			return false, d.deleteChangeSet(w, chset, exists)
+
+		case "?":
+			fmt.Fprintf(w, "%s", reviewHelp)
+
+		default:
+			fmt.Fprintf(w, "Unknown command %s. Enter ? for help.\n", command)
+		}
+	}
+}
+
+func findResourceChange(chset *cf.DescribeChangeSetOutput, logicalId string) *cf.ResourceChange {
+	for _, change := range chset.Changes {
+		rc := change.ResourceChange
+		if rc != nil && aws.StringValue(rc.LogicalResourceId) == logicalId {
+			return rc
+		}
+	}
+
+	return nil
+}
+
+// pageChangeSet shows the full change set as JSON in $PAGER, or in less if
+// it is not set.
+func pageChangeSet(w io.Writer, chset *cf.DescribeChangeSetOutput) error {
+	data, err := json.MarshalIndent(chset, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal change set")
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+
+	return errors.Wrapf(cmd.Run(), "run pager %s", pager[0])
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeployer_Review(t *testing.T) {
+	chset := &cf.DescribeChangeSetOutput{
+		StackName:     aws.String("foo"),
+		ChangeSetName: aws.String("StackUpdate-1"),
+		Status:        aws.String(cf.ChangeSetStatusCreateComplete),
+		Changes: []*cf.Change{
+			{
+				Type: aws.String(cf.ChangeTypeResource),
+				ResourceChange: &cf.ResourceChange{
+					Action:             aws.String(cf.ChangeActionModify),
+					LogicalResourceId:  aws.String("Bucket"),
+					PhysicalResourceId: aws.String("foo-bucket"),
+					ResourceType:       aws.String("AWS::S3::Bucket"),
+					Replacement:        aws.String(cf.ReplacementFalse),
+					Scope:              aws.StringSlice([]string{"Properties"}),
+				},
+			},
+		},
+	}
+
+	review := func(input string) (*cleanupAPI, string, bool, error) {
+		api := &cleanupAPI{}
+		d := NewDeployer(api, &cftool.Deployment{StackName: "foo"})
+		d.ChangeSetName = "StackUpdate-1"
+		d.Prompter = &Prompter{Input: bufio.NewReader(strings.NewReader(input))}
+
+		var w bytes.Buffer
+		ok, err := d.review(&w, chset, false)
+		return api, w.String(), ok, err
+	}
+
+	t.Run("execute", func(t *testing.T) {
+		api, _, ok, err := review("x\n")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, api.deletedChangeSets)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		api, out, ok, err := review("a\n")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, []string{"StackUpdate-1"}, api.deletedChangeSets)
+		assert.Equal(t, []string{"foo"}, api.deletedStacks)
+		assert.Contains(t, out, "Change set StackUpdate-1 deleted.\nStack foo deleted.\n")
+	})
+
+	t.Run("closed stdin", func(t *testing.T) {
+		api, _, ok, err := review("t\n")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Empty(t, api.deletedChangeSets)
+	})
+
+	t.Run("shared input", func(t *testing.T) {
+		d := NewDeployer(&cleanupAPI{}, &cftool.Deployment{StackName: "foo"})
+		d.Prompter = &Prompter{Input: bufio.NewReader(strings.NewReader("x\ny\n"))}
+
+		var w bytes.Buffer
+		ok, err := d.review(&w, chset, true)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// The answer after the review is not lost to its read-ahead.
+		ok, err = d.Prompter.Confirm(&w, ApproveDeleteFailed, "\nContinue?")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("investigate", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "cftool-test")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "report.md")
+		_, out, ok, err := review("bogus\nr\nr Queue\nr Bucket\ns " + path + "\nx\n")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		assert.Contains(t, out, "Unknown command bogus. Enter ? for help.\n")
+		assert.Contains(t, out, "Usage: r LOGICAL-ID\n")
+		assert.Contains(t, out, "Resource Queue is not in the change set.\n")
+		assert.Contains(t, out, "~ AWS::S3::Bucket Bucket\n"+
+			"  Resource: foo-bucket\n"+
+			"   Replace: False\n"+
+			"     Scope: Properties\n")
+		assert.Contains(t, out, "Report written to "+path+".\n")
+
+		report, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(report), "### Stack `foo`: change set `StackUpdate-1`\n")
+	})
+
+	t.Run("pager", func(t *testing.T) {
+		pager := os.Getenv("PAGER")
+		defer os.Setenv("PAGER", pager)
+		require.NoError(t, os.Setenv("PAGER", "cat"))
+
+		_, out, _, err := review("v\n")
+		require.NoError(t, err)
+		assert.Contains(t, out, `"ChangeSetName": "StackUpdate-1"`)
+	})
+}
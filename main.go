@@ -11,7 +11,7 @@ func main() {
 	err := cli.Entry(context.Background(), os.Args)
 
 	if err != nil {
-		fmt.Printf("ERROR: %v", err)
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 		os.Exit(1)
 	}
 }
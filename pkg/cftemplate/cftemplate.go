@@ -0,0 +1,236 @@
+// Package cftemplate parses CloudFormation templates written in JSON or YAML.
+package cftemplate
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+// Template is a parsed CloudFormation template.
+type Template struct {
+	// Sections holds the top-level sections of the template. Values are
+	// maps, slices, strings or nil. Scalars are kept in their textual form,
+	// and YAML short-form intrinsic functions (e.g. !Ref) are rewritten into
+	// their long form (e.g. {"Ref": ...}), so that equivalent JSON and YAML
+	// templates produce the same value.
+	Sections map[string]interface{}
+
+	// Parameters are the template's declared parameters, in declaration
+	// order.
+	Parameters []*Parameter
+}
+
+type Parameter struct {
+	Name          string
+	Type          string
+	Description   string
+	Default       *string
+	AllowedValues []string
+	NoEcho        bool
+}
+
+// Parse parses a template body.
+func Parse(body []byte) (*Template, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(body, &doc); err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+
+	root := &doc
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return &Template{Sections: map[string]interface{}{}}, nil
+		}
+
+		root = root.Content[0]
+	}
+
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("parse template: expected a mapping at the top level")
+	}
+
+	value, err := convert(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+
+	t := &Template{Sections: value.(map[string]interface{})}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "Parameters" {
+			continue
+		}
+
+		declared, _ := t.Sections["Parameters"].(map[string]interface{})
+		for _, name := range mappingKeys(root.Content[i+1]) {
+			t.Parameters = append(t.Parameters, newParameter(name, declared[name]))
+		}
+	}
+
+	return t, nil
+}
+
+// Parameter returns the declared parameter with the given name, or nil.
+func (t *Template) Parameter(name string) *Parameter {
+	for _, p := range t.Parameters {
+		if p.Name == name {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func newParameter(name string, v interface{}) *Parameter {
+	p := &Parameter{Name: name}
+	props, _ := v.(map[string]interface{})
+
+	p.Type, _ = props["Type"].(string)
+	p.Description, _ = props["Description"].(string)
+
+	if def, ok := props["Default"]; ok {
+		s := scalarOrList(def)
+		p.Default = &s
+	}
+
+	if values, ok := props["AllowedValues"].([]interface{}); ok {
+		for _, value := range values {
+			p.AllowedValues = append(p.AllowedValues, scalarOrList(value))
+		}
+	}
+
+	noEcho, _ := props["NoEcho"].(string)
+	p.NoEcho = strings.EqualFold(noEcho, "true")
+
+	return p
+}
+
+// scalarOrList renders a parameter default the way CloudFormation accepts it
+// as a parameter value: lists are joined by commas.
+func scalarOrList(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = scalarOrList(item)
+		}
+
+		return strings.Join(items, ",")
+	default:
+		return ""
+	}
+}
+
+func mappingKeys(node *yaml.Node) []string {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+
+	return keys
+}
+
+func convert(node *yaml.Node) (interface{}, error) {
+	var value interface{}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return convert(node.Alias)
+
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return convert(node.Content[0])
+
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v, err := convert(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			m[node.Content[i].Value] = v
+		}
+
+		// The long form of GetAtt accepts "Resource.Attribute" as well as a
+		// list; normalize into the list.
+		if s, ok := m["Fn::GetAtt"].(string); ok && len(m) == 1 {
+			m["Fn::GetAtt"] = splitGetAtt(s)
+		}
+
+		value = m
+
+	case yaml.SequenceNode:
+		s := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			v, err := convert(item)
+			if err != nil {
+				return nil, err
+			}
+
+			s[i] = v
+		}
+
+		value = s
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			value = nil
+		case "!!bool":
+			value = strings.ToLower(node.Value)
+		default:
+			value = node.Value
+		}
+
+	default:
+		return nil, errors.Errorf("line %d: unexpected yaml node", node.Line)
+	}
+
+	return intrinsic(node.Tag, value), nil
+}
+
+// intrinsic rewrites a value tagged with a short-form intrinsic function into
+// its long form. Values without such a tag are returned as they are.
+func intrinsic(tag string, value interface{}) interface{} {
+	if !strings.HasPrefix(tag, "!") || strings.HasPrefix(tag, "!!") {
+		return value
+	}
+
+	name := tag[1:]
+
+	switch name {
+	case "Ref", "Condition":
+		return map[string]interface{}{name: value}
+
+	case "GetAtt":
+		if s, ok := value.(string); ok {
+			value = splitGetAtt(s)
+		}
+	}
+
+	return map[string]interface{}{"Fn::" + name: value}
+}
+
+func splitGetAtt(s string) interface{} {
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) != 2 {
+		return s
+	}
+
+	return []interface{}{parts[0], parts[1]}
+}
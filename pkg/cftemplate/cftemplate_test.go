@@ -0,0 +1,63 @@
+package cftemplate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string) *Template {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	tpl, err := Parse(data)
+	require.NoError(t, err)
+	return tpl
+}
+
+func TestParse(t *testing.T) {
+	yml := parseFile(t, "testdata/template.yml")
+	json := parseFile(t, "testdata/template.json")
+
+	assert.Equal(t, json.Sections, yml.Sections)
+	assert.Equal(t, json.Parameters, yml.Parameters)
+
+	port, subnets := "80", "a,b"
+	assert.Equal(t, []*Parameter{
+		{
+			Name:          "Environment",
+			Type:          "String",
+			Description:   "Deployment environment.",
+			AllowedValues: []string{"live", "test"},
+		},
+		{Name: "Password", Type: "String", NoEcho: true},
+		{Name: "Subnets", Type: "CommaDelimitedList", Default: &subnets},
+		{Name: "Port", Type: "Number", Default: &port},
+	}, yml.Parameters)
+
+	queue := yml.Sections["Resources"].(map[string]interface{})["Queue"]
+	assert.Equal(t, map[string]interface{}{
+		"Type": "AWS::SQS::Queue",
+		"Properties": map[string]interface{}{
+			"QueueName": map[string]interface{}{"Fn::Sub": "${Environment}-queue"},
+			"Tags": []interface{}{
+				map[string]interface{}{
+					"Key":   "Arn",
+					"Value": map[string]interface{}{"Fn::GetAtt": []interface{}{"Topic", "Arn"}},
+				},
+				map[string]interface{}{
+					"Key":   "Topic",
+					"Value": map[string]interface{}{"Ref": "Topic"},
+				},
+			},
+		},
+	}, queue)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("- a\n- b\n"))
+	require.Error(t, err)
+
+	_, err = Parse([]byte("Resources: [\n"))
+	require.Error(t, err)
+}
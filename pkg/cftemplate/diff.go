@@ -0,0 +1,203 @@
+package cftemplate
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdd    ChangeKind = "Add"
+	ChangeRemove ChangeKind = "Remove"
+	ChangeModify ChangeKind = "Modify"
+)
+
+// Change is a difference between two templates.
+type Change struct {
+	Kind ChangeKind
+
+	// Section is the top-level section, e.g. Resources.
+	Section string
+
+	// LogicalId is the entry within the section, e.g. the logical ID of a
+	// resource. It is empty for sections that are not keyed by logical ID,
+	// such as Description.
+	LogicalId string
+
+	// Path locates the changed value within the entry, e.g.
+	// Properties.Tags[0].Value. It is empty if the entry as a whole was
+	// added, removed or modified.
+	Path string
+
+	Old interface{}
+	New interface{}
+}
+
+// SectionOrder is the order of the sections of a template, as documented.
+var SectionOrder = []string{
+	"AWSTemplateFormatVersion",
+	"Description",
+	"Metadata",
+	"Parameters",
+	"Rules",
+	"Mappings",
+	"Conditions",
+	"Transform",
+	"Resources",
+	"Outputs",
+}
+
+// entrySections are the sections keyed by logical ID.
+var entrySections = map[string]bool{
+	"Parameters": true,
+	"Rules":      true,
+	"Mappings":   true,
+	"Conditions": true,
+	"Resources":  true,
+	"Outputs":    true,
+}
+
+// Diff returns the structural differences between two templates, ordered by
+// section, then by logical ID, then by path. Formatting, key order and the
+// choice between JSON and YAML (including short-form intrinsic functions)
+// make no difference.
+func Diff(old, new *Template) []Change {
+	var changes []Change
+
+	for _, section := range sectionNames(old.Sections, new.Sections) {
+		a, inOld := old.Sections[section]
+		b, inNew := new.Sections[section]
+
+		am, aok := a.(map[string]interface{})
+		bm, bok := b.(map[string]interface{})
+
+		if !entrySections[section] || !aok && inOld || !bok && inNew {
+			changes = diffValue(changes, Change{Section: section}, a, inOld, b, inNew)
+			continue
+		}
+
+		for _, id := range sortedKeys(am, bm) {
+			a, inOld := am[id]
+			b, inNew := bm[id]
+			changes = diffValue(changes, Change{Section: section, LogicalId: id}, a, inOld, b, inNew)
+		}
+	}
+
+	return changes
+}
+
+func diffValue(
+	changes []Change,
+	at Change,
+	a interface{}, inOld bool,
+	b interface{}, inNew bool,
+) []Change {
+	switch {
+	case !inOld && !inNew:
+		return changes
+
+	case !inOld:
+		at.Kind, at.New = ChangeAdd, b
+		return append(changes, at)
+
+	case !inNew:
+		at.Kind, at.Old = ChangeRemove, a
+		return append(changes, at)
+
+	case reflect.DeepEqual(a, b):
+		return changes
+	}
+
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok && !isIntrinsic(a) && !isIntrinsic(b) {
+			for _, key := range sortedKeys(a, b) {
+				va, ina := a[key]
+				vb, inb := b[key]
+				next := at
+				next.Path = joinPath(at.Path, key)
+				changes = diffValue(changes, next, va, ina, vb, inb)
+			}
+
+			return changes
+		}
+
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok && len(a) == len(b) {
+			for i := range a {
+				next := at
+				next.Path = fmt.Sprintf("%s[%d]", at.Path, i)
+				changes = diffValue(changes, next, a[i], true, b[i], true)
+			}
+
+			return changes
+		}
+	}
+
+	at.Kind, at.Old, at.New = ChangeModify, a, b
+	return append(changes, at)
+}
+
+// isIntrinsic reports whether m is an intrinsic function, which is compared
+// as a whole.
+func isIntrinsic(m map[string]interface{}) bool {
+	if len(m) != 1 {
+		return false
+	}
+
+	for key := range m {
+		return key == "Ref" || key == "Condition" || len(key) > 4 && key[:4] == "Fn::"
+	}
+
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func sectionNames(a, b map[string]interface{}) []string {
+	known := make(map[string]bool)
+	var result []string
+
+	for _, name := range SectionOrder {
+		known[name] = true
+		_, ina := a[name]
+		_, inb := b[name]
+		if ina || inb {
+			result = append(result, name)
+		}
+	}
+
+	var unknown []string
+	for _, name := range sortedKeys(a, b) {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	return append(result, unknown...)
+}
+
+func sortedKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := make([]string, 0, len(a)+len(b))
+
+	for _, m := range []map[string]interface{}{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
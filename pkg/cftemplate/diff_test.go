@@ -0,0 +1,69 @@
+package cftemplate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	yml := parseFile(t, "testdata/template.yml")
+	json := parseFile(t, "testdata/template.json")
+	changed := parseFile(t, "testdata/template-changed.yml")
+
+	assert.Empty(t, Diff(json, yml))
+
+	getAtt := func(resource, attribute string) interface{} {
+		return map[string]interface{}{"Fn::GetAtt": []interface{}{resource, attribute}}
+	}
+
+	sub := func(s string) interface{} {
+		return map[string]interface{}{"Fn::Sub": s}
+	}
+
+	assert.Equal(t, []Change{
+		{Kind: ChangeAdd, Section: "Description", New: "Changed."},
+		{
+			Kind:      ChangeModify,
+			Section:   "Parameters",
+			LogicalId: "Environment",
+			Path:      "AllowedValues",
+			Old:       []interface{}{"live", "test"},
+			New:       []interface{}{"live", "test", "dev"},
+		},
+		{
+			Kind:      ChangeRemove,
+			Section:   "Parameters",
+			LogicalId: "Password",
+			Old:       map[string]interface{}{"Type": "String", "NoEcho": "true"},
+		},
+		{
+			Kind:      ChangeAdd,
+			Section:   "Resources",
+			LogicalId: "Bucket",
+			New:       map[string]interface{}{"Type": "AWS::S3::Bucket"},
+		},
+		{
+			Kind:      ChangeModify,
+			Section:   "Resources",
+			LogicalId: "Queue",
+			Path:      "Properties.QueueName",
+			Old:       sub("${Environment}-queue"),
+			New:       sub("${Environment}-jobs"),
+		},
+		{
+			Kind:      ChangeModify,
+			Section:   "Resources",
+			LogicalId: "Queue",
+			Path:      "Properties.Tags[0].Value",
+			Old:       getAtt("Topic", "Arn"),
+			New:       getAtt("Topic", "TopicName"),
+		},
+		{
+			Kind:      ChangeAdd,
+			Section:   "Resources",
+			LogicalId: "Queue",
+			Path:      "Properties.VisibilityTimeout",
+			New:       "60",
+		},
+	}, Diff(yml, changed))
+}
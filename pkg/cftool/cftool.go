@@ -9,8 +9,21 @@ type Deployment struct {
 	AccountId    string
 	Region       string
 	StackName    string
+	TemplatePath string
 	TemplateBody []byte
 	Parameters   map[string]string
+
+	// ParameterSources maps each parameter key to the parameter file its
+	// value was read from, or to "inline" if it was given in the manifest.
+	ParameterSources map[string]string
+
+	// StackPolicy is a stack policy document in JSON, or empty.
+	StackPolicy string
+
+	// StackTags are applied to the stack. They come from template
+	// configurations only; Tags are used for templating. If there are none,
+	// the tags of an existing stack are left as they are.
+	StackTags map[string]string
 }
 
 type Parameters map[string]string
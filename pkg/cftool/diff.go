@@ -0,0 +1,58 @@
+package cftool
+
+import (
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"sort"
+)
+
+// MaskedValue replaces the values of NoEcho parameters, as it does in the
+// output of CloudFormation.
+const MaskedValue = "****"
+
+// ValueChange is a difference between two sets of parameters or tags.
+type ValueChange struct {
+	Kind cftemplate.ChangeKind
+	Key  string
+	Old  string
+	New  string
+}
+
+// DiffValues returns the differences between two sets of parameters or
+// tags, ordered by key. The values of masked keys are replaced by
+// MaskedValue. As their current values cannot be known, masked keys that are
+// present in both sets are never reported as changed.
+func DiffValues(old, new map[string]string, masked map[string]bool) []ValueChange {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var changes []ValueChange
+	for _, k := range keys {
+		a, inOld := old[k]
+		b, inNew := new[k]
+
+		if masked[k] {
+			a, b = MaskedValue, MaskedValue
+		}
+
+		switch {
+		case !inOld:
+			changes = append(changes, ValueChange{Kind: cftemplate.ChangeAdd, Key: k, New: b})
+		case !inNew:
+			changes = append(changes, ValueChange{Kind: cftemplate.ChangeRemove, Key: k, Old: a})
+		case a != b:
+			changes = append(changes, ValueChange{Kind: cftemplate.ChangeModify, Key: k, Old: a, New: b})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,37 @@
+package cftool
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"testing"
+)
+
+func TestDiffValues(t *testing.T) {
+	old := map[string]string{
+		"Same":      "a",
+		"Changed":   "b",
+		"Removed":   "c",
+		"Secret":    MaskedValue,
+		"OldSecret": MaskedValue,
+	}
+
+	new := map[string]string{
+		"Same":      "a",
+		"Changed":   "B",
+		"Added":     "d",
+		"Secret":    "hunter2",
+		"NewSecret": "hunter3",
+	}
+
+	masked := map[string]bool{"Secret": true, "OldSecret": true, "NewSecret": true}
+
+	assert.Equal(t, []ValueChange{
+		{Kind: cftemplate.ChangeAdd, Key: "Added", New: "d"},
+		{Kind: cftemplate.ChangeModify, Key: "Changed", Old: "b", New: "B"},
+		{Kind: cftemplate.ChangeAdd, Key: "NewSecret", New: MaskedValue},
+		{Kind: cftemplate.ChangeRemove, Key: "OldSecret", Old: MaskedValue},
+		{Kind: cftemplate.ChangeRemove, Key: "Removed", Old: "c"},
+	}, DiffValues(old, new, masked))
+
+	assert.Empty(t, DiffValues(old, old, nil))
+}
@@ -0,0 +1,79 @@
+package manifest
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// resolveExtends applies the Extends property of every stack. A stack
+// inherits the defaults, targets, constants and tags of the stack it
+// extends, which may in turn extend another. Inherited defaults are layered
+// beneath the stack's own by Defaults.MergeFrom, so that the stack's values
+// take precedence, and targets are only inherited if the stack has none of
+// its own.
+func (m *Manifest) resolveExtends() error {
+	stacks := make(map[string]*Stack)
+	for _, stack := range m.Stacks {
+		if _, ok := stacks[stack.Label]; !ok {
+			stacks[stack.Label] = stack
+		}
+	}
+
+	resolved := make(map[*Stack]bool)
+
+	var resolve func(stack *Stack, chain []string) error
+	resolve = func(stack *Stack, chain []string) error {
+		if resolved[stack] || stack.Extends == "" {
+			return nil
+		}
+
+		chain = append(chain, stack.Label)
+
+		base, ok := stacks[stack.Extends]
+		if !ok {
+			return errors.Errorf(
+				"%s: Extends %s", stack.describe(),
+				notFoundMessage("stack", stack.Extends, m.stackLabelsAll(), nil))
+		}
+
+		for _, label := range chain {
+			if label == base.Label {
+				return errors.Errorf(
+					"%s: Extends cycle: %s -> %s",
+					stack.describe(), strings.Join(chain, " -> "), base.Label)
+			}
+		}
+
+		if err := resolve(base, chain); err != nil {
+			return err
+		}
+
+		def := Defaults{}.MergeFrom(base.Default).MergeFrom(stack.Default)
+		stack.Default = &def
+
+		if len(stack.Targets) == 0 {
+			stack.Targets = base.Targets
+		}
+
+		constants := make(map[string]string)
+		extendMap(constants, base.Constants)
+		extendMap(constants, stack.Constants)
+		stack.Constants = constants
+
+		tags := make(map[string]string)
+		extendMap(tags, base.Tags)
+		extendMap(tags, stack.Tags)
+		stack.Tags = tags
+
+		resolved[stack] = true
+		return nil
+	}
+
+	for _, stack := range m.Stacks {
+		if err := resolve(stack, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
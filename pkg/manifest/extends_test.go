@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestManifest_Extends(t *testing.T) {
+	m, err := ReadFromFile("testdata/extends-manifest.yml")
+	require.NoError(t, err)
+
+	deployments, err := m.FindDeployments([]string{"*"}, []string{"*"})
+	require.NoError(t, err)
+	require.Len(t, deployments, 3)
+
+	orders := deployments[0]
+	assert.Equal(t, "orders", orders.StackLabel)
+	assert.Equal(t, "live", orders.TenantLabel)
+	assert.Equal(t, "live-orders", orders.StackName)
+	assert.Equal(t, "eu-west-1", orders.Region)
+	assert.False(t, orders.Protected)
+	assert.Equal(t, map[string]string{"Foo": "queue", "Environment": "live"}, orders.Parameters)
+
+	assert.Equal(t, "orders", deployments[1].StackLabel)
+	assert.Equal(t, "test", deployments[1].TenantLabel)
+	assert.Equal(t, "us-east-1", deployments[1].Region)
+
+	billing := deployments[2]
+	assert.Equal(t, "billing", billing.StackLabel)
+	assert.Equal(t, "live", billing.TenantLabel)
+	assert.True(t, billing.Protected)
+
+	assert.Equal(t, map[string]string{"Team": "platform", "Service": "orders"}, m.Stacks[2].Tags)
+
+	_, err = m.FindDeployment("live", "base")
+	require.EqualError(t, err, `stack "base" not found in manifest; available stacks: orders, billing`)
+}
+
+func TestManifest_ExtendsInvalid(t *testing.T) {
+	tests := []struct {
+		Stacks string
+		Error  string
+	}{
+		{
+			"  - {Label: a, Extends: b}\n  - {Label: b, Extends: c}\n  - {Label: c, Extends: a}\n",
+			"stack c: Extends cycle: a -> b -> c -> a",
+		},
+		{
+			"  - {Label: a, Extends: a}\n",
+			"stack a: Extends cycle: a -> a",
+		},
+		{
+			"  - {Label: base}\n  - {Label: a, Extends: bsae}\n",
+			`stack a: Extends stack "bsae" not found in manifest (did you mean "base"?); available stacks: base, a`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := Read(strings.NewReader("Version: \"1.2\"\nStacks:\n" + test.Stacks))
+			require.EqualError(t, err, test.Error)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"encoding/json"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// fragment is the content of a file included by a manifest.
+type fragment struct {
+	Tenants []*Tenant
+	Stacks  []*Stack
+}
+
+// fragmentSchema derives the schema of an included file from the Tenants
+// and Stacks sections of the newest manifest schema.
+func fragmentSchema() ([]byte, error) {
+	var schema map[string]interface{}
+	if err := yaml.Unmarshal(manifestSchema, &schema); err != nil {
+		return nil, err
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+
+	return json.Marshal(map[string]interface{}{
+		"$schema":              schema["$schema"],
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"Tenants": properties["Tenants"],
+			"Stacks":  properties["Stacks"],
+		},
+		"definitions": schema["definitions"],
+	})
+}
+
+// resolveIncludes merges the tenants and stacks of every file matched by the
+// Include patterns into the manifest. Patterns are relative to dir. A pattern
+// without wildcards must match an existing file.
+func (m *Manifest) resolveIncludes(dir string) error {
+	if len(m.Include) == 0 {
+		return nil
+	}
+
+	schema, err := fragmentSchema()
+	if err != nil {
+		return errors.Wrap(err, "fragment schema")
+	}
+
+	for _, pattern := range m.Include {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return errors.Wrapf(err, "include %s", pattern)
+		}
+
+		if len(paths) == 0 && !isPattern(pattern) {
+			return errors.Errorf("include %s: file not found", pattern)
+		}
+
+		for _, path := range paths {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return errors.Wrapf(err, "include %s", pattern)
+			}
+
+			var f fragment
+			if err := unmarshalWithValidation(data, schema, &f); err != nil {
+				return errors.Wrapf(err, "include %s", path)
+			}
+
+			for _, tenant := range f.Tenants {
+				tenant.Source = path
+			}
+
+			for _, stack := range f.Stacks {
+				stack.Source = path
+			}
+
+			m.Tenants = append(m.Tenants, f.Tenants...)
+			m.Stacks = append(m.Stacks, f.Stacks...)
+		}
+	}
+
+	return nil
+}
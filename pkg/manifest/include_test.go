@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestReadFromFile_Include(t *testing.T) {
+	m, err := ReadFromFile("testdata/include/manifest.yml")
+	require.NoError(t, err)
+
+	var tenants, stacks []string
+	for _, tenant := range m.Tenants {
+		tenants = append(tenants, tenant.Label+" "+tenant.Source)
+	}
+
+	for _, stack := range m.Stacks {
+		stacks = append(stacks, stack.Label+" "+stack.Source)
+	}
+
+	assert.Equal(t, []string{
+		"live ",
+		"test testdata/include/tenants.yml",
+	}, tenants)
+
+	assert.Equal(t, []string{
+		"a testdata/include/stacks/a.yml",
+		"b testdata/include/stacks/b.yml",
+		"a testdata/include/stacks/b.yml",
+	}, stacks)
+
+	problems := m.validateLabels()
+	require.Len(t, problems, 1)
+	assert.EqualError(
+		t, problems[0],
+		"duplicate stack a (testdata/include/stacks/b.yml), first declared by testdata/include/stacks/a.yml")
+}
+
+func TestReadFromFile_IncludeInvalid(t *testing.T) {
+	_, err := ReadFromFile("testdata/include-invalid/manifest.yml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include testdata/include-invalid/fragment.yml: ")
+	assert.Contains(t, err.Error(), "Additional property Tragets is not allowed")
+
+	_, err = Read(strings.NewReader("Version: \"1.2\"\nInclude: [missing.yml]\n"))
+	require.EqualError(t, err, "include missing.yml: file not found")
+
+	_, err = Read(strings.NewReader("Version: \"1.1\"\nInclude: [missing.yml]\n"))
+	require.Error(t, err)
+}
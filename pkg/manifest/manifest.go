@@ -1,13 +1,20 @@
 package manifest
 
 import (
+	"fmt"
+	"github.com/pkg/errors"
 	"github.com/tetratom/cftool/pkg/cftool"
 	"io/ioutil"
+	"os"
+	"path"
+	"sort"
 	"strings"
 	"text/template"
 )
 
-const SupportedVersion = "1.1"
+// InlineParameterSource is the source of parameters whose values are given
+// directly in the manifest, rather than in a parameter file.
+const InlineParameterSource = "inline"
 
 type Global struct {
 	Constants map[string]string
@@ -20,18 +27,52 @@ type Tenant struct {
 	Default   *Defaults
 	Constants map[string]string
 	Tags      map[string]string
+
+	// Source is the path of the included file that declared the tenant, or
+	// empty if it was declared by the manifest itself.
+	Source string `json:"-"`
 }
 
 type Stack struct {
-	Label   string
-	Default *Defaults
-	Targets []*Target
-	Tags    map[string]string
+	Label     string
+	Default   *Defaults
+	Targets   []*Target
+	Constants map[string]string
+	Tags      map[string]string
+
+	// Abstract stacks are never deployed, but can be extended by others.
+	Abstract bool
+
+	// Extends is the label of a stack whose defaults, targets and tags are
+	// inherited by this stack.
+	Extends string
+
+	// Source is the path of the included file that declared the stack, or
+	// empty if it was declared by the manifest itself.
+	Source string `json:"-"`
+}
+
+func (t *Tenant) describe() string {
+	return describe("tenant", t.Label, t.Source)
+}
+
+func (s *Stack) describe() string {
+	return describe("stack", s.Label, s.Source)
+}
+
+func describe(kind string, label string, source string) string {
+	if source == "" {
+		return kind + " " + label
+	}
+
+	return fmt.Sprintf("%s %s (%s)", kind, label, source)
 }
 
 type Target struct {
-	Tenant   string
-	Override *Defaults
+	Tenant    string
+	Override  *Defaults
+	Constants map[string]string
+	Tags      map[string]string
 }
 
 type Defaults struct {
@@ -41,6 +82,9 @@ type Defaults struct {
 	// Region is an AWS region, if different from the profile's default.
 	Region string
 
+	// Regions deploys to each of several AWS regions, replacing Region.
+	Regions []string
+
 	// Template is the path of a template file relative to Config.
 	Template string
 
@@ -66,10 +110,18 @@ func (d Defaults) MergeFrom(other *Defaults) Defaults {
 	}
 
 	add(&d.AccountId, &other.AccountId)
-	add(&d.Region, &other.Region)
 	add(&d.Template, &other.Template)
 	add(&d.StackName, &other.StackName)
 
+	// a layer specifying either Region or Regions replaces both
+	if other.Region != "" {
+		d.Region, d.Regions = other.Region, nil
+	}
+
+	if len(other.Regions) > 0 {
+		d.Region, d.Regions = "", other.Regions
+	}
+
 	for _, p := range other.Parameters {
 		d.Parameters = append(d.Parameters, p)
 	}
@@ -82,8 +134,15 @@ func (d Defaults) MergeFrom(other *Defaults) Defaults {
 }
 
 type Parameter struct {
-	// File is the path of a parameter file relative to Config.
-	File  string
+	// File is the path of a parameter file relative to Config. The content
+	// of the file is templated in the same way as the manifest.
+	File string
+
+	// Optional parameter files are skipped if they do not exist. This allows
+	// layering, e.g. common.json, then <tenant>.json, then
+	// <tenant>/<region>.json.
+	Optional bool
+
 	Key   string
 	Value string
 }
@@ -93,6 +152,18 @@ type Manifest struct {
 	Global  Global
 	Tenants []*Tenant
 	Stacks  []*Stack
+
+	// Include contains glob patterns, relative to the manifest, of files
+	// whose Tenants and Stacks are merged into the manifest.
+	Include []string
+
+	// Groups maps a group name to a list of tenant labels, tenant patterns
+	// or the names of other groups.
+	Groups map[string][]string
+
+	// Policy is the path of a policy file, relative to the manifest, whose
+	// rules apply to the change sets of every deployment.
+	Policy string
 }
 
 func applyTemplate(text string, data interface{}) (string, error) {
@@ -114,17 +185,46 @@ func applyTemplate(text string, data interface{}) (string, error) {
 	return w.String(), nil
 }
 
+// EscapeTemplate escapes text so that templating leaves it unchanged, for
+// values written to files that a manifest templates, such as parameter
+// files.
+func EscapeTemplate(text string) string {
+	return strings.Replace(text, "{{", "{{`{{`}}", -1)
+}
+
 func extendMap(a, b map[string]string) {
 	for k, v := range b {
 		a[k] = v
 	}
 }
 
+// Deployment resolves a target that deploys to a single region. Use
+// Deployments for targets that may deploy to several regions.
 func (m *Manifest) Deployment(
 	tenant *Tenant,
 	stack *Stack,
 	target *Target,
-) (result *cftool.Deployment, err error) {
+) (*cftool.Deployment, error) {
+	deployments, err := m.Deployments(tenant, stack, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deployments) != 1 {
+		return nil, errors.Errorf(
+			"%s %s: expected a single region, but target deploys to %s",
+			tenant.describe(), stack.describe(), strings.Join(regionsOf(deployments), ", "))
+	}
+
+	return deployments[0], nil
+}
+
+// Deployments resolves a target into one deployment per region.
+func (m *Manifest) Deployments(
+	tenant *Tenant,
+	stack *Stack,
+	target *Target,
+) (result []*cftool.Deployment, err error) {
 	def := Defaults{}.
 		MergeFrom(m.Global.Default).
 		MergeFrom(tenant.Default).
@@ -151,13 +251,18 @@ func (m *Manifest) Deployment(
 	tpl["TenantLabel"] = d.TenantLabel
 	tpl["StackLabel"] = d.StackLabel
 
+	// constants and tags are specialized by each layer in turn
 	extendMap(constants, m.Global.Constants)
 	extendMap(constants, tenant.Constants)
+	extendMap(constants, stack.Constants)
+	extendMap(constants, target.Constants)
 	tpl["Constants"] = constants
 	d.Constants = constants
 
 	extendMap(tags, m.Global.Tags)
 	extendMap(tags, tenant.Tags)
+	extendMap(tags, stack.Tags)
+	extendMap(tags, target.Tags)
 	for k, v := range tags {
 		tags[k], err = applyTemplate(v, tpl)
 		if err != nil {
@@ -173,7 +278,38 @@ func (m *Manifest) Deployment(
 	}
 	tpl["AccountId"] = d.AccountId
 
-	d.Region, err = applyTemplate(def.Region, tpl)
+	regions := def.Regions
+	if len(regions) == 0 {
+		regions = []string{def.Region}
+	}
+
+	for _, region := range regions {
+		// each region continues from a copy of the values so far
+		regional := d
+		regionalTpl := make(map[string]interface{})
+		for k, v := range tpl {
+			regionalTpl[k] = v
+		}
+
+		err = m.resolveRegion(&regional, regionalTpl, def, region)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &regional)
+	}
+
+	return result, nil
+}
+
+// resolveRegion completes a deployment for a single region.
+func (m *Manifest) resolveRegion(
+	d *cftool.Deployment,
+	tpl map[string]interface{},
+	def Defaults,
+	region string,
+) (err error) {
+	d.Region, err = applyTemplate(region, tpl)
 	if err != nil {
 		return
 	}
@@ -190,66 +326,334 @@ func (m *Manifest) Deployment(
 	if err != nil {
 		return
 	}
+	d.TemplatePath = templatePath
 	d.TemplateBody, err = ioutil.ReadFile(templatePath)
 	if err != nil {
-		return nil, err
+		return errors.Wrap(err, "read template")
 	}
 
 	d.Parameters = make(map[string]string)
+	d.ParameterSources = make(map[string]string)
 	for _, p := range def.Parameters {
 		switch {
 		case p.File != "":
 			path, err := applyTemplate(p.File, tpl)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			kvp, err := ReadParametersFromFile(path)
+			file, err := readParameterFile(path, tpl)
+			if p.Optional && os.IsNotExist(errors.Cause(err)) {
+				continue
+			}
 			if err != nil {
-				return nil, err
+				return errors.Wrap(err, "read parameter file")
+			}
+			extendMap(d.Parameters, file.Parameters)
+			for k := range file.Parameters {
+				d.ParameterSources[k] = path
+			}
+			if len(file.Tags) > 0 {
+				if d.StackTags == nil {
+					d.StackTags = make(map[string]string)
+				}
+
+				extendMap(d.StackTags, file.Tags)
+			}
+			if file.StackPolicy != "" {
+				d.StackPolicy = file.StackPolicy
 			}
-			extendMap(d.Parameters, kvp)
 		default:
 			d.Parameters[p.Key], err = applyTemplate(p.Value, tpl)
 			if err != nil {
 				return
 			}
+			d.ParameterSources[p.Key] = InlineParameterSource
+		}
+	}
+
+	return nil
+}
+
+// readParameterFile reads a parameter file referenced by the manifest,
+// applying the template to its content first. A literal "{{" in the file
+// must therefore be written as {{`{{`}}.
+func readParameterFile(path string, tpl map[string]interface{}) (*ParameterFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := applyTemplate(string(data), tpl)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	file, err := ParseParameterFile([]byte(text))
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	return file, nil
+}
+
+func regionsOf(deployments []*cftool.Deployment) []string {
+	regions := make([]string, len(deployments))
+	for i, d := range deployments {
+		regions[i] = orDefault(d.Region)
+	}
+
+	return regions
+}
+
+// FindDeployment returns the deployment of a single stack to a single
+// tenant. It is an error if either label is unknown, if the stack does not
+// target the tenant, or if the target deploys to several regions.
+func (m *Manifest) FindDeployment(tenantLabel string, stackLabel string) (*cftool.Deployment, error) {
+	deployments, err := m.FindDeployments([]string{tenantLabel}, []string{stackLabel})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deployments) != 1 {
+		return nil, errors.Errorf(
+			"tenant %s stack %s deploys to several regions: %s",
+			tenantLabel, stackLabel, strings.Join(regionsOf(deployments), ", "))
+	}
+
+	return deployments[0], nil
+}
+
+// FindDeployments returns every deployment whose tenant matches one of
+// tenantPatterns and whose stack matches one of stackPatterns. Patterns use
+// the syntax of path.Match, and a tenant pattern naming a group selects the
+// members of that group. Deployments are returned in manifest order: by
+// stack, and then by target.
+//
+// It is an error if any pattern matches nothing, if a stack selected by its
+// exact label targets none of the selected tenants, or if the selection is
+// empty. Errors list the available tenants and stacks, and suggest close
+// matches for mistyped labels.
+func (m *Manifest) FindDeployments(tenantPatterns []string, stackPatterns []string) ([]*cftool.Deployment, error) {
+	if len(tenantPatterns) == 0 {
+		return nil, errors.Errorf(
+			"no tenant selected; available tenants: %s", listOrNone(m.tenantLabels()))
+	}
+
+	if len(stackPatterns) == 0 {
+		return nil, errors.Errorf(
+			"no stack selected; available stacks: %s", listOrNone(m.stackLabels()))
+	}
+
+	tenants, err := m.matchTenants(tenantPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*cftool.Deployment
+	var selected []*Stack
+	for _, pattern := range stackPatterns {
+		found := false
+		for _, stack := range m.Stacks {
+			if stack.Abstract {
+				continue
+			}
+
+			ok, err := path.Match(pattern, stack.Label)
+			if err != nil {
+				return nil, errors.Wrapf(err, "stack pattern %s", pattern)
+			}
+
+			if ok {
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, errors.New(notFoundMessage("stack", pattern, m.stackLabels(), nil))
+		}
+	}
+
+	for _, stack := range m.Stacks {
+		if stack.Abstract {
+			continue
+		}
+
+		ok, err := matchAny(stackPatterns, stack.Label)
+		if err != nil {
+			return nil, errors.Wrap(err, "stack pattern")
+		}
+
+		if !ok {
+			continue
+		}
+
+		selected = append(selected, stack)
+		count := 0
+
+		for _, target := range stack.Targets {
+			tenant, ok := tenants[target.Tenant]
+			if !ok {
+				continue
+			}
+
+			deployments, err := m.Deployments(tenant, stack, target)
+			if err != nil {
+				return nil, errors.Wrapf(
+					err, "%s %s", tenant.describe(), stack.describe())
+			}
+
+			result = append(result, deployments...)
+			count++
+		}
+
+		if count == 0 && containsString(stackPatterns, stack.Label) {
+			return nil, m.noTargetError(stack, tenantPatterns)
 		}
 	}
 
-	return &d, nil
+	if len(result) == 0 {
+		return nil, m.noTargetError(selected[0], tenantPatterns)
+	}
+
+	return result, nil
 }
 
-func (m *Manifest) FindDeployment(tenantLabel string, stackLabel string) (*cftool.Deployment, bool, error) {
-	var tenant *Tenant
-	for _, t := range m.Tenants {
-		if t.Label == tenantLabel {
-			tenant = t
-			break
+func (m *Manifest) noTargetError(stack *Stack, tenantPatterns []string) error {
+	targets := make([]string, len(stack.Targets))
+	for i, target := range stack.Targets {
+		targets[i] = target.Tenant
+	}
+
+	return errors.Errorf(
+		"stack %q does not target tenant %s; it targets: %s",
+		stack.Label,
+		strings.Join(tenantPatterns, ", "),
+		listOrNone(targets))
+}
+
+func (m *Manifest) tenantLabels() []string {
+	labels := make([]string, len(m.Tenants))
+	for i, tenant := range m.Tenants {
+		labels[i] = tenant.Label
+	}
+
+	return labels
+}
+
+// stackLabels returns the labels of stacks that can be deployed.
+func (m *Manifest) stackLabels() []string {
+	var labels []string
+	for _, stack := range m.Stacks {
+		if !stack.Abstract {
+			labels = append(labels, stack.Label)
 		}
 	}
-	if tenant == nil {
-		return nil, false, nil
+
+	return labels
+}
+
+func (m *Manifest) stackLabelsAll() []string {
+	labels := make([]string, len(m.Stacks))
+	for i, stack := range m.Stacks {
+		labels[i] = stack.Label
 	}
 
-	var stack *Stack
-	var target *Target
-	for _, s := range m.Stacks {
-		if s.Label == stackLabel {
-			stack = s
-			for _, t := range stack.Targets {
-				if t.Tenant == tenantLabel {
-					target = t
-					break
+	return labels
+}
+
+func (m *Manifest) groupNames() []string {
+	names := make([]string, 0, len(m.Groups))
+	for name := range m.Groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// matchTenants resolves tenant patterns and group names to a set of tenants
+// keyed by label. Every pattern must match at least one tenant.
+func (m *Manifest) matchTenants(patterns []string) (map[string]*Tenant, error) {
+	result := make(map[string]*Tenant)
+	expanded := make(map[string]bool)
+
+	var expand func(pattern string) (bool, error)
+	expand = func(pattern string) (bool, error) {
+		found := false
+
+		if members, ok := m.Groups[pattern]; ok {
+			found = true
+
+			if !expanded[pattern] {
+				expanded[pattern] = true
+				for _, member := range members {
+					ok, err := expand(member)
+					if err != nil {
+						return false, err
+					}
+
+					if !ok {
+						return false, errors.Errorf(
+							"group %s: %s",
+							pattern,
+							notFoundMessage("tenant", member, m.tenantLabels(), nil))
+					}
 				}
 			}
-			break
+		}
+
+		for _, tenant := range m.Tenants {
+			ok, err := path.Match(pattern, tenant.Label)
+			if err != nil {
+				return false, errors.Wrapf(err, "tenant pattern %s", pattern)
+			}
+
+			if ok {
+				result[tenant.Label] = tenant
+				found = true
+			}
+		}
+
+		return found, nil
+	}
+
+	for _, pattern := range patterns {
+		ok, err := expand(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, errors.New(
+				notFoundMessage("tenant", pattern, m.tenantLabels(), m.groupNames()))
+		}
+	}
+
+	return result, nil
+}
+
+func matchAny(patterns []string, label string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, label)
+		if err != nil {
+			return false, errors.Wrapf(err, "pattern %s", pattern)
+		}
+
+		if ok {
+			return true, nil
 		}
 	}
-	if stack == nil || target == nil {
-		return nil, false, nil
+
+	return false, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
 	}
 
-	d, err := m.Deployment(tenant, stack, target)
-	return d, true, err
+	return false
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/tetratom/cftool/pkg/cftool"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -36,7 +37,13 @@ func TestManifest_FindDeployment(t *testing.T) {
 					"Environment": "test",
 					"SomeConst":   "const",
 				},
+				ParameterSources: map[string]string{
+					"Foo":         "testdata/stacks/test/eu-west-1/test-mystack.json",
+					"Environment": "inline",
+					"SomeConst":   "inline",
+				},
 				StackName:    "test-mystack",
+				TemplatePath: "testdata/templates/mystack.yml",
 				TemplateBody: readAll("testdata/templates/mystack.yml"),
 				Region:       "eu-west-1",
 				Protected:    false,
@@ -64,7 +71,13 @@ func TestManifest_FindDeployment(t *testing.T) {
 					"Environment": "live",
 					"SomeConst":   "bax",
 				},
+				ParameterSources: map[string]string{
+					"Foo":         "testdata/stacks/live-us/us-west-1/live-mystack-us.json",
+					"Environment": "inline",
+					"SomeConst":   "inline",
+				},
 				StackName:    "live-mystack-us",
+				TemplatePath: "testdata/templates/mystack.yml",
 				TemplateBody: readAll("testdata/templates/mystack.yml"),
 				Region:       "us-west-1",
 				Protected:    true,
@@ -90,10 +103,217 @@ func TestManifest_FindDeployment(t *testing.T) {
 			require.NoError(t, err)
 			m, err := Read(f)
 			require.NoError(t, err)
-			actual, found, err := m.FindDeployment(test.TenantLabel, test.StackLabel)
+			actual, err := m.FindDeployment(test.TenantLabel, test.StackLabel)
 			require.NoError(t, err)
-			require.True(t, found)
 			assert.Equal(t, test.Expect, actual)
 		})
 	}
 }
+
+func TestManifest_FindDeployments(t *testing.T) {
+	tests := []struct {
+		Tenants []string
+		Stacks  []string
+		Expect  []string
+	}{
+		{[]string{"test"}, []string{"mystack"}, []string{"test"}},
+		{[]string{"live*"}, []string{"my*"}, []string{"live", "live-us"}},
+		{[]string{"test", "live"}, []string{"mystack"}, []string{"live", "test"}},
+		{[]string{"prod"}, []string{"mystack"}, []string{"live", "live-us"}},
+		{[]string{"everything"}, []string{"*"}, []string{"live", "live-us", "test"}},
+		{[]string{"live-us", "prod"}, []string{"mystack"}, []string{"live", "live-us"}},
+	}
+
+	f, err := os.Open("testdata/groups-manifest.yml")
+	require.NoError(t, err)
+	defer f.Close()
+	m, err := Read(f)
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			deployments, err := m.FindDeployments(test.Tenants, test.Stacks)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, d := range deployments {
+				actual = append(actual, d.TenantLabel)
+			}
+
+			assert.Equal(t, test.Expect, actual)
+		})
+	}
+}
+
+func TestManifest_FindDeployments_NotFound(t *testing.T) {
+	tests := []struct {
+		Tenants []string
+		Stacks  []string
+		Expect  string
+	}{
+		{
+			[]string{"tset"},
+			[]string{"mystack"},
+			`tenant "tset" not found in manifest (did you mean "test"?); ` +
+				`available tenants: live, live-us, test; available groups: everything, prod`,
+		},
+		{
+			[]string{"staging-*"},
+			[]string{"mystack"},
+			`tenant "staging-*" not found in manifest; ` +
+				`available tenants: live, live-us, test; available groups: everything, prod`,
+		},
+		{
+			[]string{"test"},
+			[]string{"mystak"},
+			`stack "mystak" not found in manifest (did you mean "mystack"?); available stacks: mystack`,
+		},
+		{
+			[]string{"test"},
+			[]string{"other"},
+			`stack "other" not found in manifest; available stacks: mystack`,
+		},
+		{
+			[]string{"test"},
+			nil,
+			`no stack selected; available stacks: mystack`,
+		},
+	}
+
+	f, err := os.Open("testdata/groups-manifest.yml")
+	require.NoError(t, err)
+	defer f.Close()
+	m, err := Read(f)
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := m.FindDeployments(test.Tenants, test.Stacks)
+			require.EqualError(t, err, test.Expect)
+		})
+	}
+
+	t.Run("no target", func(t *testing.T) {
+		m.Tenants = append(m.Tenants, &Tenant{Label: "staging"})
+		_, err := m.FindDeployment("staging", "mystack")
+		require.EqualError(
+			t, err, `stack "mystack" does not target tenant staging; it targets: live, live-us, test`)
+	})
+}
+
+func TestManifest_Deployment_Layers(t *testing.T) {
+	m, err := ReadFromFile("testdata/layers-manifest.yml")
+	require.NoError(t, err)
+
+	d, err := m.FindDeployment("live", "layered")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"Layer":      "target",
+		"GlobalOnly": "global",
+		"StackOnly":  "stack",
+	}, d.Constants)
+
+	assert.Equal(t, map[string]string{
+		"Layer":   "target",
+		"Owner":   "global",
+		"Service": "stack",
+		"Target":  "yes",
+	}, d.Tags)
+
+	assert.Equal(t, "stack-target", d.StackName)
+}
+
+func TestManifest_Deployments_Regions(t *testing.T) {
+	m, err := ReadFromFile("testdata/regions-manifest.yml")
+	require.NoError(t, err)
+
+	deployments, err := m.FindDeployments([]string{"*"}, []string{"edge"})
+	require.NoError(t, err)
+
+	var actual []string
+	for _, d := range deployments {
+		actual = append(actual, d.Region+" "+d.StackName)
+	}
+
+	assert.Equal(t, []string{
+		"eu-west-1 live-edge-eu-west-1",
+		"us-east-1 live-edge-us-east-1",
+		"ap-southeast-2 live-edge-apac",
+		"eu-west-1 test-edge-eu-west-1",
+	}, actual)
+
+	_, err = m.FindDeployment("live", "edge")
+	require.EqualError(
+		t, err, "tenant live stack edge deploys to several regions: eu-west-1, us-east-1, ap-southeast-2")
+
+	d, err := m.FindDeployment("test", "edge")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", d.Region)
+
+	_, err = Read(strings.NewReader(
+		"Version: \"1.2\"\nGlobal:\n  Default:\n    Region: a\n    Regions: [b]\n"))
+	require.Error(t, err)
+}
+
+func TestManifest_Deployments_OptionalParameterFiles(t *testing.T) {
+	m, err := ReadFromFile("testdata/optional-params-manifest.yml")
+	require.NoError(t, err)
+
+	deployments, err := m.FindDeployments([]string{"*"}, []string{"params"})
+	require.NoError(t, err)
+	require.Len(t, deployments, 4)
+
+	live := deployments[0]
+	assert.Equal(t, "eu-west-1", live.Region)
+	assert.Equal(t, map[string]string{
+		"Environment": "live-env",
+		"Foo":         "blue-live",
+		"SomeConst":   "eu-west-1",
+	}, live.Parameters)
+	assert.Equal(t, map[string]string{
+		"Environment": "testdata/params/common.json",
+		"Foo":         "testdata/params/live.json",
+		"SomeConst":   "testdata/params/live/eu-west-1.json",
+	}, live.ParameterSources)
+
+	assert.Equal(t, "us-east-1", deployments[1].Region)
+	assert.Equal(t, map[string]string{
+		"Environment": "live-env",
+		"Foo":         "blue-live",
+	}, deployments[1].Parameters)
+
+	test := deployments[2]
+	assert.Equal(t, map[string]string{
+		"Environment": "test-env",
+		"Foo":         "common",
+	}, test.Parameters)
+
+	m.Stacks[0].Default.Parameters[1].Optional = false
+	_, err = m.FindDeployments([]string{"test"}, []string{"params"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "testdata/params/test.json")
+}
+
+func TestManifest_Deployment_StackTags(t *testing.T) {
+	m, err := ReadFromFile("testdata/stack-tags-manifest.yml")
+	require.NoError(t, err)
+
+	d, err := m.FindDeployment("live", "configured")
+	require.NoError(t, err)
+
+	// Manifest tags are only for templating; only those of template
+	// configurations are applied to the stack.
+	assert.Equal(t, map[string]string{"Env": "live"}, d.Tags)
+	assert.Equal(t, map[string]string{"CostCenter": "1234"}, d.StackTags)
+}
+
+func TestReadParameterFile_Escaped(t *testing.T) {
+	tpl := map[string]interface{}{"TenantLabel": "live"}
+
+	file, err := readParameterFile("testdata/EscapedParameterFile.json", tpl)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Password": "{{resolve:ssm-secure:/live/password}}",
+	}, file.Parameters)
+}
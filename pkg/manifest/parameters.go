@@ -0,0 +1,183 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ParameterFile is the content of a parameter file. Three formats are
+// accepted, in JSON or YAML, and detected automatically: the CloudFormation
+// list of ParameterKey and ParameterValue pairs; a plain map of parameter
+// keys to values; and the CodePipeline template configuration, which is a
+// map with Parameters, Tags and StackPolicy.
+//
+// In the latter two formats, values may be strings, numbers or booleans, or
+// lists of these for CommaDelimitedList parameters.
+type ParameterFile struct {
+	Parameters map[string]string
+
+	// Tags are stack tags. Only template configurations declare tags.
+	Tags map[string]string
+
+	// StackPolicy is a stack policy document in JSON, or empty. Only
+	// template configurations declare a stack policy.
+	StackPolicy string
+}
+
+// ParseParameterFile parses the content of a parameter file of any format.
+func ParseParameterFile(data []byte) (*ParameterFile, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := decodeJSON(data, &doc); err != nil {
+		return nil, err
+	}
+
+	result := &ParameterFile{Parameters: make(map[string]string)}
+
+	switch doc := doc.(type) {
+	case []interface{}:
+		var params []cloudformation.Parameter
+		if err := unmarshalWithValidation(data, parametersSchema, &params); err != nil {
+			return nil, err
+		}
+
+		for _, param := range params {
+			result.Parameters[*param.ParameterKey] = *param.ParameterValue
+		}
+
+	case map[string]interface{}:
+		if _, ok := doc["Parameters"].(map[string]interface{}); ok {
+			return parseTemplateConfiguration(data)
+		}
+
+		if err := validateSchema(parameterMapSchema, data); err != nil {
+			return nil, err
+		}
+
+		for k, v := range doc {
+			result.Parameters[k] = parameterValue(v)
+		}
+
+	default:
+		return nil, errors.New("expected a list or a map of parameters")
+	}
+
+	return result, nil
+}
+
+func parseTemplateConfiguration(data []byte) (*ParameterFile, error) {
+	if err := validateSchema(templateConfigurationSchema, data); err != nil {
+		return nil, errors.Wrap(err, "template configuration")
+	}
+
+	var config struct {
+		Parameters  map[string]interface{}
+		Tags        map[string]string
+		StackPolicy json.RawMessage
+	}
+
+	if err := decodeJSON(data, &config); err != nil {
+		return nil, err
+	}
+
+	result := &ParameterFile{
+		Parameters: make(map[string]string),
+		Tags:       config.Tags,
+	}
+
+	for k, v := range config.Parameters {
+		result.Parameters[k] = parameterValue(v)
+	}
+
+	if len(config.StackPolicy) > 0 {
+		result.StackPolicy = string(config.StackPolicy)
+	}
+
+	return result, nil
+}
+
+// decodeJSON decodes numbers as json.Number, to preserve them exactly.
+func decodeJSON(data []byte, out interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(out)
+}
+
+// parameterValue converts a value to the string CloudFormation expects.
+// Lists are joined by commas.
+func parameterValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+
+		return "false"
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = parameterValue(item)
+		}
+
+		return strings.Join(items, ",")
+	default:
+		return ""
+	}
+}
+
+func ReadParameterFile(r io.Reader) (*ParameterFile, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseParameterFile(data)
+}
+
+func ReadParameterFileFromFile(path string) (*ParameterFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	params, err := ReadParameterFile(f)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	return params, nil
+}
+
+func ReadParameters(r io.Reader) (map[string]string, error) {
+	params, err := ReadParameterFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return params.Parameters, nil
+}
+
+func ReadParametersFromFile(path string) (map[string]string, error) {
+	params, err := ReadParameterFileFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return params.Parameters, nil
+}
@@ -0,0 +1,48 @@
+package manifest
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/policy"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// ReadPolicy reads a policy file in JSON or YAML.
+func ReadPolicy(r io.Reader) (*policy.Policy, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var p policy.Policy
+	if err := unmarshalWithValidation(data, policySchema, &p); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range p.Rules {
+		for _, pattern := range []string{rule.ResourceType, rule.LogicalId} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errors.Wrapf(err, "rule %d: pattern %q", i+1, pattern)
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+func ReadPolicyFromFile(path string) (*policy.Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p, err := ReadPolicy(f)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	return p, nil
+}
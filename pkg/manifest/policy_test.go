@@ -0,0 +1,28 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/policy"
+	"strings"
+	"testing"
+)
+
+func TestReadPolicyFromFile(t *testing.T) {
+	p, err := ReadPolicyFromFile("testdata/policy.yml")
+	require.NoError(t, err)
+	require.Len(t, p.Rules, 3)
+	assert.Equal(t, &policy.Rule{
+		ResourceType: "AWS::RDS::*",
+		Replacement:  []string{"True", "Conditional"},
+		Verdict:      policy.Deny,
+		Reason:       "Databases must not be replaced.",
+	}, p.Rules[0])
+
+	_, err = ReadPolicyFromFile("testdata/invalid-policy.yml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "testdata/invalid-policy.yml")
+
+	_, err = ReadPolicy(strings.NewReader("Rules:\n  - LogicalId: \"[\"\n    Verdict: warn\n"))
+	require.Error(t, err)
+}
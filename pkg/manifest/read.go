@@ -1,21 +1,15 @@
 package manifest
 
 import (
-	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/ghodss/yaml"
-	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
-func readWithValidation(r io.Reader, schema []byte, out interface{}) error {
-	data, err := ioutil.ReadAll(r)
-	if err != nil {
-		return err
-	}
-
-	err = validateSchema(schema, data)
+func unmarshalWithValidation(data []byte, schema []byte, out interface{}) error {
+	err := validateSchema(schema, data)
 	if err != nil {
 		return err
 	}
@@ -28,49 +22,47 @@ func readWithValidation(r io.Reader, schema []byte, out interface{}) error {
 	return nil
 }
 
+// Read reads a manifest of any of the SupportedVersions. It is validated
+// against the schema of its declared version. Included files are resolved
+// relative to the working directory.
 func Read(r io.Reader) (*Manifest, error) {
-	var m Manifest
-	err := readWithValidation(r, manifestSchema, &m)
+	return read(r, ".")
+}
+
+func read(r io.Reader, dir string) (*Manifest, error) {
+	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
-	if m.Version != SupportedVersion {
-		return nil, errors.Errorf("expected version %s", SupportedVersion)
+	i, err := findVersion(data)
+	if err != nil {
+		return nil, err
 	}
 
-	return &m, nil
-}
-
-func ReadFromFile(path string) (*Manifest, error) {
-	f, err := os.Open(path)
+	var m Manifest
+	err = unmarshalWithValidation(data, manifestVersions[i].Schema, &m)
 	if err != nil {
 		return nil, err
 	}
 
-	return Read(f)
-}
-
-func ReadParameters(r io.Reader) (map[string]string, error) {
-	var params []cloudformation.Parameter
-	err := readWithValidation(r, parametersSchema, &params)
-	if err != nil {
+	if err := m.resolveIncludes(dir); err != nil {
 		return nil, err
 	}
 
-	result := make(map[string]string)
-	for _, param := range params {
-		result[*param.ParameterKey] = *param.ParameterValue
+	if err := m.resolveExtends(); err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return &m, nil
 }
 
-func ReadParametersFromFile(path string) (map[string]string, error) {
+func ReadFromFile(path string) (*Manifest, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	return ReadParameters(f)
+	return read(f, filepath.Dir(path))
 }
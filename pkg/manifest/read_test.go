@@ -22,6 +22,18 @@ func TestReadParametersFromFile(t *testing.T) {
 			"testdata/ParameterFile1.json",
 			map[string]string{"A": "B", "C": "D"},
 		},
+		{
+			"testdata/ParameterMap.yml",
+			map[string]string{"A": "B", "Port": "8080", "Enabled": "true", "Subnets": "subnet-1,subnet-2"},
+		},
+		{
+			"testdata/ParameterMap.json",
+			map[string]string{"A": "B", "Port": "8080", "Enabled": "true", "Subnets": "subnet-1,subnet-2"},
+		},
+		{
+			"testdata/TemplateConfiguration.json",
+			map[string]string{"A": "B", "Subnets": "subnet-1,subnet-2"},
+		},
 	}
 
 	for _, test := range tests {
@@ -32,3 +44,31 @@ func TestReadParametersFromFile(t *testing.T) {
 		})
 	}
 }
+
+func TestReadParameterFileFromFile_TemplateConfiguration(t *testing.T) {
+	actual, err := ReadParameterFileFromFile("testdata/TemplateConfiguration.json")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"CostCenter": "1234"}, actual.Tags)
+	require.JSONEq(
+		t,
+		`{"Statement": [{"Effect": "Allow", "Action": "Update:*", "Principal": "*", "Resource": "*"}]}`,
+		actual.StackPolicy)
+}
+
+func TestParseParameterFile_Invalid(t *testing.T) {
+	tests := []string{
+		`"just a string"`,
+		`[{"ParameterKey": "A"}]`,
+		`{"A": {"B": "C"}}`,
+		`{"A": [["B"]]}`,
+		`{"Parameters": {"A": "B"}, "Tags": {"A": 1}}`,
+		`{"Parameters": {"A": "B"}, "Unknown": {}}`,
+	}
+
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			_, err := ParseParameterFile([]byte(test))
+			require.Error(t, err)
+		})
+	}
+}
@@ -28,7 +28,7 @@ func validateSchema(schema []byte, data []byte) error {
 	}
 
 	if !result.Valid() {
-		validationErrors := make([]string, len(result.Errors()))
+		validationErrors := make([]string, 0, len(result.Errors()))
 
 		for _, resultError := range result.Errors() {
 			validationErrors = append(validationErrors, resultError.String())
@@ -32,6 +32,26 @@ func main() {
 		log.Fatal(err)
 	}
 
+	err = writeVarFromFile(f, "parameterMapSchema", "schemas/parametermap.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writeVarFromFile(f, "templateConfigurationSchema", "schemas/templateconfiguration.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writeVarFromFile(f, "policySchema", "schemas/policy.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writeVarFromFile(f, "manifestSchemaV1_1", "schemas/manifest-1.1.yml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	err = writeVarFromFile(f, "manifestSchema", "schemas/manifest.yml")
 	if err != nil {
 		log.Fatal(err)
@@ -15,7 +15,93 @@ items:
     ParameterValue:
       type: string
 `)
-var manifestSchema = []byte(`
+var parameterMapSchema = []byte(`
+$schema: "http://json-schema.org/draft-07/schema#"
+type: object
+additionalProperties:
+  $ref: "#/definitions/ParameterValue"
+
+definitions:
+  Scalar:
+    type: [string, number, boolean]
+
+  ParameterValue:
+    oneOf:
+      - $ref: "#/definitions/Scalar"
+      - type: array
+        items:
+          $ref: "#/definitions/Scalar"
+`)
+var templateConfigurationSchema = []byte(`
+$schema: "http://json-schema.org/draft-07/schema#"
+type: object
+additionalProperties: false
+required:
+  - Parameters
+properties:
+  Parameters:
+    type: object
+    additionalProperties:
+      $ref: "#/definitions/ParameterValue"
+  Tags:
+    type: object
+    additionalProperties:
+      type: string
+  StackPolicy:
+    type: object
+    required:
+      - Statement
+    properties:
+      Statement:
+        type: array
+
+definitions:
+  Scalar:
+    type: [string, number, boolean]
+
+  ParameterValue:
+    oneOf:
+      - $ref: "#/definitions/Scalar"
+      - type: array
+        items:
+          $ref: "#/definitions/Scalar"
+`)
+var policySchema = []byte(`
+$schema: "http://json-schema.org/draft-07/schema#"
+type: object
+additionalProperties: false
+required:
+  - Rules
+properties:
+  Rules:
+    type: array
+    items:
+      type: object
+      additionalProperties: false
+      required:
+        - Verdict
+      properties:
+        ResourceType:
+          type: string
+        LogicalId:
+          type: string
+        Action:
+          type: array
+          items:
+            type: string
+            enum: [Add, Modify, Remove, Import, Dynamic]
+        Replacement:
+          type: array
+          items:
+            type: string
+            enum: ["True", "Conditional", "False"]
+        Verdict:
+          type: string
+          enum: [warn, require-confirmation, deny]
+        Reason:
+          type: string
+`)
+var manifestSchemaV1_1 = []byte(`
 $schema: "http://json-schema.org/draft-07/schema#"
 type: object
 additionalProperties: false
@@ -62,6 +148,12 @@ properties:
           type: array
           items:
             $ref: "#/definitions/Target"
+  Groups:
+    type: object
+    additionalProperties:
+      type: array
+      items:
+        type: string
 
 definitions:
   TagSet:
@@ -119,4 +211,148 @@ definitions:
       Override:
         $ref: "#/definitions/Stack"
 `)
+var manifestSchema = []byte(`
+$schema: "http://json-schema.org/draft-07/schema#"
+type: object
+additionalProperties: false
+required:
+  - Version
+properties:
+  Version:
+    type: string
+    enum: ["1.2"]
+  Global:
+    type: object
+    additionalProperties: false
+    properties:
+      Constants:
+        $ref: "#/definitions/TagSet"
+      Default:
+        $ref: "#/definitions/Stack"
+      Tags:
+        $ref: "#/definitions/TagSet"
+  Tenants:
+    type: array
+    items:
+      type: object
+      additionalProperties: false
+      required:
+        - Label
+      properties:
+        Label:
+          type: string
+        Constants:
+          $ref: "#/definitions/TagSet"
+        Default:
+          $ref: "#/definitions/Stack"
+        Tags:
+          $ref: "#/definitions/TagSet"
+  Stacks:
+    type: array
+    items:
+      type: object
+      additionalProperties: false
+      required:
+        - Label
+      properties:
+        Label:
+          type: string
+        Abstract:
+          type: boolean
+        Extends:
+          type: string
+        Constants:
+          $ref: "#/definitions/TagSet"
+        Default:
+          $ref: "#/definitions/Stack"
+        Tags:
+          $ref: "#/definitions/TagSet"
+        Targets:
+          type: array
+          items:
+            $ref: "#/definitions/Target"
+  Include:
+    type: array
+    items:
+      type: string
+  Groups:
+    type: object
+    additionalProperties:
+      type: array
+      items:
+        type: string
+  Policy:
+    type: string
+
+definitions:
+  TagSet:
+    type: object
+    additionalProperties:
+      type: string
+
+  Parameter:
+    oneOf:
+      - type: object
+        additionalProperties: false
+        required:
+          - File
+        properties:
+          File:
+            type: string
+          Optional:
+            type: boolean
+      - type: object
+        additionalProperties: false
+        required:
+          - Key
+          - Value
+        properties:
+          Key:
+            type: string
+          Value:
+            type: string
+
+  Stack:
+    type: object
+    additionalProperties: false
+    not:
+      required:
+        - Region
+        - Regions
+    properties:
+      AccountId:
+        type: string
+      Parameters:
+        type: array
+        items:
+          $ref: "#/definitions/Parameter"
+      Protected:
+        type: boolean
+      Region:
+        type: string
+      Regions:
+        type: array
+        minItems: 1
+        items:
+          type: string
+      StackName:
+        type: string
+      Template:
+        type: string
+
+  Target:
+    type: object
+    additionalProperties: false
+    required:
+      - Tenant
+    properties:
+      Tenant:
+        type: string
+      Constants:
+        $ref: "#/definitions/TagSet"
+      Override:
+        $ref: "#/definitions/Stack"
+      Tags:
+        $ref: "#/definitions/TagSet"
+`)
 
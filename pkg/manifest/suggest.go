@@ -0,0 +1,101 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+)
+
+func isPattern(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+// suggest returns the candidate closest to name, or an empty string if none
+// of the candidates are similar enough to be a likely typo.
+func suggest(name string, candidates []string) string {
+	best := ""
+	bestDistance := len(name)/3 + 1
+	if bestDistance < 2 {
+		bestDistance = 2
+	}
+
+	for _, candidate := range candidates {
+		if strings.EqualFold(name, candidate) {
+			return candidate
+		}
+
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(candidate))
+		if distance <= bestDistance && (best == "" || distance < bestDistance) {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
+
+// notFoundMessage describes a selector that matched nothing, suggesting the
+// closest candidate and listing what is available. Groups are listed
+// separately, but are considered for suggestions.
+func notFoundMessage(kind string, name string, candidates []string, groups []string) string {
+	msg := fmt.Sprintf("%s %q not found in manifest", kind, name)
+
+	if !isPattern(name) {
+		if s := suggest(name, append(candidates, groups...)); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+	}
+
+	msg += fmt.Sprintf("; available %ss: %s", kind, listOrNone(candidates))
+
+	if len(groups) > 0 {
+		msg += fmt.Sprintf("; available groups: %s", strings.Join(groups, ", "))
+	}
+
+	return msg
+}
+
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "(none)"
+	}
+
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,177 @@
+package manifest
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"sort"
+)
+
+// Validate checks the whole manifest without deploying anything, and returns
+// every problem found along with the number of deployments that were
+// checked. Paths in the manifest are resolved relative to the working
+// directory.
+//
+// Validate checks that labels are unique, that groups and targets refer to
+// existing tenants, that the policy file (if any) is valid, that every
+// deployment can be rendered (which includes
+// reading its template and parameter files), that parameters match those
+// declared by the template, and that no two deployments resolve to the same
+// stack.
+func (m *Manifest) Validate() (problems []error, deployments int) {
+	problems = append(problems, m.validateLabels()...)
+	problems = append(problems, m.validateGroups()...)
+
+	if m.Policy != "" {
+		if _, err := ReadPolicyFromFile(m.Policy); err != nil {
+			problems = append(problems, errors.Wrap(err, "policy"))
+		}
+	}
+
+	tenants := make(map[string]*Tenant)
+	for _, tenant := range m.Tenants {
+		if _, ok := tenants[tenant.Label]; !ok {
+			tenants[tenant.Label] = tenant
+		}
+	}
+
+	stacks := make(map[string]string)
+
+	for _, stack := range m.Stacks {
+		if stack.Abstract {
+			continue
+		}
+
+		for _, target := range stack.Targets {
+			tenant, ok := tenants[target.Tenant]
+			if !ok {
+				problems = append(problems, errors.Errorf(
+					"%s: target %s",
+					stack.describe(),
+					notFoundMessage("tenant", target.Tenant, m.tenantLabels(), nil)))
+				continue
+			}
+
+			where := tenant.describe() + " " + stack.describe()
+
+			resolved, err := m.Deployments(tenant, stack, target)
+			if err != nil {
+				problems = append(problems, errors.Wrap(err, where))
+				continue
+			}
+
+			for _, d := range resolved {
+				deployments++
+
+				where := where
+				if len(resolved) > 1 {
+					where += " region " + d.Region
+				}
+
+				for _, err := range validateParameters(d) {
+					problems = append(problems, errors.Wrap(err, where))
+				}
+
+				key := fmt.Sprintf(
+					"account %s region %s stack name %s",
+					orDefault(d.AccountId), orDefault(d.Region), d.StackName)
+
+				if other, ok := stacks[key]; ok {
+					problems = append(problems, errors.Errorf(
+						"%s: resolves to the same stack as %s (%s)", where, other, key))
+				} else {
+					stacks[key] = where
+				}
+			}
+		}
+	}
+
+	return problems, deployments
+}
+
+func (m *Manifest) validateLabels() (problems []error) {
+	seen := make(map[string]*Tenant)
+	for _, tenant := range m.Tenants {
+		if other, ok := seen[tenant.Label]; ok {
+			problems = append(problems, errors.Errorf(
+				"duplicate %s, first declared by %s",
+				tenant.describe(), sourceOrManifest(other.Source)))
+		} else {
+			seen[tenant.Label] = tenant
+		}
+	}
+
+	seenStacks := make(map[string]*Stack)
+	for _, stack := range m.Stacks {
+		if other, ok := seenStacks[stack.Label]; ok {
+			problems = append(problems, errors.Errorf(
+				"duplicate %s, first declared by %s",
+				stack.describe(), sourceOrManifest(other.Source)))
+		} else {
+			seenStacks[stack.Label] = stack
+		}
+	}
+
+	return problems
+}
+
+func sourceOrManifest(source string) string {
+	if source == "" {
+		return "the manifest"
+	}
+
+	return source
+}
+
+func (m *Manifest) validateGroups() (problems []error) {
+	for _, name := range m.groupNames() {
+		if _, err := m.matchTenants([]string{name}); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	return problems
+}
+
+// validateParameters compares the parameters of a deployment with those
+// declared by its template.
+func validateParameters(d *cftool.Deployment) (problems []error) {
+	t, err := cftemplate.Parse(d.TemplateBody)
+	if err != nil {
+		return []error{errors.Wrap(err, d.TemplatePath)}
+	}
+
+	var keys []string
+	for key := range d.Parameters {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if t.Parameter(key) == nil {
+			problems = append(problems, errors.Errorf(
+				"parameter %s (from %s) is not declared by template %s",
+				key, d.ParameterSources[key], d.TemplatePath))
+		}
+	}
+
+	for _, p := range t.Parameters {
+		if _, ok := d.Parameters[p.Name]; !ok && p.Default == nil {
+			problems = append(problems, errors.Errorf(
+				"parameter %s is required by template %s but has no value",
+				p.Name, d.TemplatePath))
+		}
+	}
+
+	return problems
+}
+
+func orDefault(s string) string {
+	if s == "" {
+		return "(default)"
+	}
+
+	return s
+}
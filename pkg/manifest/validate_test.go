@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestManifest_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		m, err := ReadFromFile("testdata/groups-manifest.yml")
+		require.NoError(t, err)
+		problems, deployments := m.Validate()
+		assert.Empty(t, problems)
+		assert.Equal(t, 3, deployments)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		m, err := ReadFromFile("testdata/invalid-manifest.yml")
+		require.NoError(t, err)
+		problems, deployments := m.Validate()
+		assert.Equal(t, 2, deployments)
+
+		var actual []string
+		for _, problem := range problems {
+			actual = append(actual, problem.Error())
+		}
+
+		assert.Equal(t, []string{
+			"duplicate tenant test, first declared by the manifest",
+			`group prod: tenant "live-eu" not found in manifest (did you mean "live"?); available tenants: live, test, test`,
+			"tenant live stack mystack: parameter Extra (from inline) is not declared by template testdata/templates/mystack.yml",
+			"tenant live stack mystack: parameter Foo is required by template testdata/templates/mystack.yml but has no value",
+			"tenant test stack mystack: parameter Extra (from inline) is not declared by template testdata/templates/mystack.yml",
+			"tenant test stack mystack: parameter Foo is required by template testdata/templates/mystack.yml but has no value",
+			"tenant test stack mystack: resolves to the same stack as tenant live stack mystack " +
+				"(account 111111111111 region eu-west-1 stack name mystack)",
+			`stack mystack: target tenant "staging" not found in manifest; available tenants: live, test, test`,
+			`tenant live stack other: template: Template:1:7: executing "Template" at <.Tags.Missing>: ` +
+				`map has no entry for key "Missing"`,
+			"tenant live stack missing: read template: open testdata/templates/missing.yml: no such file or directory",
+		}, actual)
+	})
+}
@@ -0,0 +1,154 @@
+package manifest
+
+import (
+	"bytes"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	yaml3 "gopkg.in/yaml.v3"
+	"strings"
+)
+
+// SupportedVersion is the newest manifest version. Manifests of any of the
+// SupportedVersions can be read, and are normalized into the same Manifest.
+const SupportedVersion = "1.2"
+
+type manifestVersion struct {
+	Version string
+	Schema  []byte
+
+	// Upgrade rewrites a document of this version into a document of the
+	// next version. It is nil for the newest version.
+	Upgrade func(doc []byte) ([]byte, error)
+}
+
+// manifestVersions lists the supported versions, oldest first.
+var manifestVersions = []manifestVersion{
+	{"1.1", manifestSchemaV1_1, upgradeFrom1_1},
+	{SupportedVersion, manifestSchema, nil},
+}
+
+// SupportedVersions returns every manifest version that can be read, oldest
+// first.
+func SupportedVersions() []string {
+	result := make([]string, len(manifestVersions))
+	for i, v := range manifestVersions {
+		result[i] = v.Version
+	}
+
+	return result
+}
+
+func findVersion(doc []byte) (int, error) {
+	var header struct{ Version interface{} }
+	if err := yaml.Unmarshal(doc, &header); err != nil {
+		return 0, err
+	}
+
+	version, ok := header.Version.(string)
+	if !ok {
+		return 0, errors.Errorf(
+			"manifest must declare a Version as a string (one of: %s)",
+			strings.Join(SupportedVersions(), ", "))
+	}
+
+	for i, v := range manifestVersions {
+		if v.Version == version {
+			return i, nil
+		}
+	}
+
+	return 0, errors.Errorf(
+		"unsupported manifest version %q (supported: %s)",
+		version, strings.Join(SupportedVersions(), ", "))
+}
+
+// Migrate rewrites a manifest document of any supported version into the
+// newest version, and returns the version it was migrated from. Comments and
+// formatting are preserved. The result is validated against the schema of
+// the newest version, which may reject documents that older versions
+// accepted.
+func Migrate(doc []byte) (result []byte, from string, err error) {
+	i, err := findVersion(doc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	from = manifestVersions[i].Version
+
+	for ; manifestVersions[i].Upgrade != nil; i++ {
+		doc, err = manifestVersions[i].Upgrade(doc)
+		if err != nil {
+			return nil, from, errors.Wrapf(
+				err, "migrate from version %s", manifestVersions[i].Version)
+		}
+	}
+
+	if err := validateSchema(manifestSchema, doc); err != nil {
+		return nil, from, errors.Wrapf(
+			err, "migrated manifest is not valid version %s", SupportedVersion)
+	}
+
+	return doc, from, nil
+}
+
+// upgradeFrom1_1 migrates to version 1.2. The model is unchanged, but the
+// 1.2 schema validates Global, Target and Parameter strictly, where 1.1
+// silently accepted unknown properties, and it declares the Global.Default,
+// Global.Tags and Stack.Tags properties.
+func upgradeFrom1_1(doc []byte) ([]byte, error) {
+	return setVersion(doc, "1.2")
+}
+
+// setVersion replaces the value of the top-level Version property, leaving
+// the rest of the document untouched.
+func setVersion(doc []byte, version string) ([]byte, error) {
+	var node yaml3.Node
+	if err := yaml3.Unmarshal(doc, &node); err != nil {
+		return nil, err
+	}
+
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml3.MappingNode {
+		return nil, errors.New("expected a mapping at the top level")
+	}
+
+	root := node.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "Version" {
+			continue
+		}
+
+		value := root.Content[i+1]
+		lines := bytes.SplitAfter(doc, []byte("\n"))
+		line := lines[value.Line-1]
+		start := value.Column - 1
+		end := start + scalarLength(line[start:])
+
+		var replaced []byte
+		replaced = append(replaced, line[:start]...)
+		replaced = append(replaced, '"')
+		replaced = append(replaced, version...)
+		replaced = append(replaced, '"')
+		replaced = append(replaced, line[end:]...)
+		lines[value.Line-1] = replaced
+
+		return bytes.Join(lines, nil), nil
+	}
+
+	return nil, errors.New("manifest has no Version")
+}
+
+// scalarLength returns the length of the scalar token at the start of s.
+func scalarLength(s []byte) int {
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		if end := bytes.IndexByte(s[1:], s[0]); end >= 0 {
+			return end + 2
+		}
+	}
+
+	end := bytes.IndexAny(s, " \t\r\n,}#")
+	if end < 0 {
+		return len(s)
+	}
+
+	return end
+}
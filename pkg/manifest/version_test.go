@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestRead_Versions(t *testing.T) {
+	tests := []struct {
+		Input string
+		Error string
+	}{
+		{"Version: \"1.1\"\n", ""},
+		{"Version: \"1.2\"\n", ""},
+		{"Version: \"1.2\"\nStacks:\n  - Label: a\n    Tags: {A: b}\n", ""},
+		{"Version: \"1.0\"\n", `unsupported manifest version "1.0" (supported: 1.1, 1.2)`},
+		{"Version: 1.2\n", "manifest must declare a Version as a string (one of: 1.1, 1.2)"},
+		{"Global: {}\n", "manifest must declare a Version as a string (one of: 1.1, 1.2)"},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			_, err := Read(strings.NewReader(test.Input))
+			if test.Error == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.Error)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		actual, from, err := Migrate(readAll("testdata/migrate-1.1.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "1.1", from)
+		assert.Equal(t, string(readAll("testdata/migrate-1.2.yml")), string(actual))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		actual, _, err := Migrate([]byte(`{"Version": "1.1", "Tenants": []}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"Version": "1.2", "Tenants": []}`, string(actual))
+	})
+
+	t.Run("newest", func(t *testing.T) {
+		actual, from, err := Migrate(readAll("testdata/migrate-1.2.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, "1.2", from)
+		assert.Equal(t, string(readAll("testdata/migrate-1.2.yml")), string(actual))
+	})
+
+	t.Run("rejected by newer schema", func(t *testing.T) {
+		doc := "Version: \"1.1\"\nStacks:\n  - Label: a\n    Targets:\n      - Tenant: b\n        Overide: {}\n"
+		_, err := Read(strings.NewReader(doc))
+		require.NoError(t, err)
+
+		_, _, err = Migrate([]byte(doc))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "migrated manifest is not valid version 1.2")
+		assert.Contains(t, err.Error(), "Additional property Overide is not allowed")
+	})
+}
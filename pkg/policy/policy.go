@@ -0,0 +1,165 @@
+// Package policy evaluates change sets against rules that restrict risky
+// changes, such as the replacement of a database.
+package policy
+
+import (
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"path"
+)
+
+type Verdict string
+
+const (
+	// Allow is the verdict for changes that no rule matches.
+	Allow Verdict = "allow"
+
+	// Warn shows a warning, but does not stop the change.
+	Warn Verdict = "warn"
+
+	// RequireConfirmation prompts for confirmation before the change set is
+	// executed, even if prompts were otherwise disabled.
+	RequireConfirmation Verdict = "require-confirmation"
+
+	// Deny refuses to execute the change set.
+	Deny Verdict = "deny"
+)
+
+var severity = map[Verdict]int{
+	Allow:               0,
+	Warn:                1,
+	RequireConfirmation: 2,
+	Deny:                3,
+}
+
+// Severe reports whether v is at least as severe as other.
+func (v Verdict) Severe(other Verdict) bool {
+	return severity[v] >= severity[other]
+}
+
+type Policy struct {
+	Rules []*Rule
+}
+
+// Rule matches resource changes. Every condition that is given must match;
+// empty conditions match any change.
+type Rule struct {
+	// ResourceType is a pattern, e.g. AWS::RDS::*.
+	ResourceType string
+
+	// LogicalId is a pattern, e.g. *Database.
+	LogicalId string
+
+	// Action lists change set actions: Add, Modify, Remove, Import or
+	// Dynamic.
+	Action []string
+
+	// Replacement lists replacement values: True, Conditional or False.
+	Replacement []string
+
+	Verdict Verdict
+	Reason  string
+}
+
+// Finding is the verdict on a single resource change.
+type Finding struct {
+	LogicalId    string
+	ResourceType string
+	Action       string
+	Replacement  string
+	Verdict      Verdict
+
+	// Rules are the rules that matched the change, in order.
+	Rules []*Rule
+}
+
+func (r *Rule) matches(change *cf.ResourceChange) bool {
+	return matchPattern(r.ResourceType, str(change.ResourceType)) &&
+		matchPattern(r.LogicalId, str(change.LogicalResourceId)) &&
+		matchAny(r.Action, str(change.Action)) &&
+		matchAny(r.Replacement, str(change.Replacement))
+}
+
+// Evaluate returns a finding for every resource change that any rule
+// matches, in the order of the change set. The verdict of a finding is the
+// most severe of those of its rules. A nil policy matches nothing.
+func (p *Policy) Evaluate(cs *cf.DescribeChangeSetOutput) []*Finding {
+	if p == nil {
+		return nil
+	}
+
+	var findings []*Finding
+	for _, change := range cs.Changes {
+		rc := change.ResourceChange
+		if rc == nil {
+			continue
+		}
+
+		finding := &Finding{
+			LogicalId:    str(rc.LogicalResourceId),
+			ResourceType: str(rc.ResourceType),
+			Action:       str(rc.Action),
+			Replacement:  str(rc.Replacement),
+			Verdict:      Allow,
+		}
+
+		for _, rule := range p.Rules {
+			if !rule.matches(rc) {
+				continue
+			}
+
+			finding.Rules = append(finding.Rules, rule)
+			if rule.Verdict.Severe(finding.Verdict) {
+				finding.Verdict = rule.Verdict
+			}
+		}
+
+		if len(finding.Rules) > 0 {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// Overall returns the most severe verdict of the findings.
+func Overall(findings []*Finding) Verdict {
+	result := Allow
+	for _, finding := range findings {
+		if finding.Verdict.Severe(result) {
+			result = finding.Verdict
+		}
+	}
+
+	return result
+}
+
+func matchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	ok, err := path.Match(pattern, s)
+	return ok && err == nil
+}
+
+func matchAny(values []string, s string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func resourceChange(typ, id, action, replacement string) *cf.Change {
+	return &cf.Change{
+		Type: aws.String(cf.ChangeTypeResource),
+		ResourceChange: &cf.ResourceChange{
+			ResourceType:      aws.String(typ),
+			LogicalResourceId: aws.String(id),
+			Action:            aws.String(action),
+			Replacement:       aws.String(replacement),
+		},
+	}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	replaceDb := &Rule{
+		ResourceType: "AWS::RDS::*",
+		Replacement:  []string{"True", "Conditional"},
+		Verdict:      Deny,
+	}
+
+	modifyDb := &Rule{
+		ResourceType: "AWS::RDS::*",
+		Action:       []string{"Modify"},
+		Verdict:      RequireConfirmation,
+	}
+
+	removeBucket := &Rule{
+		LogicalId: "*Bucket",
+		Action:    []string{"Remove"},
+		Verdict:   Warn,
+	}
+
+	p := &Policy{Rules: []*Rule{replaceDb, modifyDb, removeBucket}}
+
+	cs := &cf.DescribeChangeSetOutput{
+		Changes: []*cf.Change{
+			resourceChange("AWS::RDS::DBInstance", "Database", "Modify", "False"),
+			resourceChange("AWS::S3::Bucket", "LogBucket", "Remove", ""),
+			resourceChange("AWS::SQS::Queue", "Queue", "Remove", ""),
+			resourceChange("AWS::RDS::DBInstance", "Replica", "Modify", "Conditional"),
+		},
+	}
+
+	findings := p.Evaluate(cs)
+	assert.Equal(t, []*Finding{
+		{
+			LogicalId:    "Database",
+			ResourceType: "AWS::RDS::DBInstance",
+			Action:       "Modify",
+			Replacement:  "False",
+			Verdict:      RequireConfirmation,
+			Rules:        []*Rule{modifyDb},
+		},
+		{
+			LogicalId:    "LogBucket",
+			ResourceType: "AWS::S3::Bucket",
+			Action:       "Remove",
+			Verdict:      Warn,
+			Rules:        []*Rule{removeBucket},
+		},
+		{
+			LogicalId:    "Replica",
+			ResourceType: "AWS::RDS::DBInstance",
+			Action:       "Modify",
+			Replacement:  "Conditional",
+			Verdict:      Deny,
+			Rules:        []*Rule{replaceDb, modifyDb},
+		},
+	}, findings)
+
+	assert.Equal(t, Deny, Overall(findings))
+	assert.Equal(t, RequireConfirmation, Overall(findings[:2]))
+	assert.Equal(t, Allow, Overall(nil))
+
+	var none *Policy
+	assert.Empty(t, none.Evaluate(cs))
+}
@@ -0,0 +1,151 @@
+package pprint
+
+import (
+	"fmt"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"io"
+	"sort"
+	"strings"
+)
+
+const replaceAction = "Replace"
+
+// markdownActions are the columns of the summary table, in order.
+var markdownActions = []string{
+	cf.ChangeActionAdd,
+	cf.ChangeActionModify,
+	cf.ChangeActionRemove,
+	replaceAction,
+}
+
+// resourceAction returns the action of a resource change, treating a
+// modification that replaces the resource as a replacement.
+func resourceAction(change *cf.ResourceChange) string {
+	if str(change.Replacement, "") == cf.ReplacementTrue {
+		return replaceAction
+	}
+
+	return str(change.Action, "")
+}
+
+// ChangeSetMarkdown renders a change set as GitHub-flavoured Markdown: a
+// table summarizing the actions per resource type, followed by collapsible
+// details per resource, suitable for a pull request comment.
+func ChangeSetMarkdown(w io.Writer, cs *cf.DescribeChangeSetOutput) {
+	fmt.Fprintf(
+		w, "### Stack `%s`: change set `%s`\n\n",
+		str(cs.StackName, "?"), str(cs.ChangeSetName, "?"))
+
+	var changes []*cf.ResourceChange
+	for _, change := range cs.Changes {
+		if str(change.Type, "") == cf.ChangeTypeResource && change.ResourceChange != nil {
+			changes = append(changes, change.ResourceChange)
+		}
+	}
+
+	if len(changes) == 0 {
+		if str(cs.Status, "") != cf.ChangeSetStatusFailed {
+			fmt.Fprintf(w, "Only outputs have changed.\n")
+		} else {
+			fmt.Fprintf(w, "No changes.\n")
+		}
+
+		return
+	}
+
+	counts := make(map[string]map[string]int)
+	totals := make(map[string]int)
+	for _, change := range changes {
+		typ := str(change.ResourceType, "?")
+		if counts[typ] == nil {
+			counts[typ] = make(map[string]int)
+		}
+
+		action := resourceAction(change)
+		counts[typ][action]++
+		totals[action]++
+	}
+
+	types := make([]string, 0, len(counts))
+	for typ := range counts {
+		types = append(types, typ)
+	}
+
+	sort.Strings(types)
+
+	fmt.Fprintf(w, "| Resource type | %s |\n", strings.Join(markdownActions, " | "))
+	fmt.Fprintf(w, "|---|%s\n", strings.Repeat("--:|", len(markdownActions)))
+
+	for _, typ := range types {
+		fmt.Fprintf(w, "| `%s` |", typ)
+		for _, action := range markdownActions {
+			fmt.Fprintf(w, " %d |", counts[typ][action])
+		}
+
+		fmt.Fprintf(w, "\n")
+	}
+
+	fmt.Fprintf(w, "| **Total** |")
+	for _, action := range markdownActions {
+		fmt.Fprintf(w, " **%d** |", totals[action])
+	}
+
+	fmt.Fprintf(w, "\n")
+
+	if count, complete := changeCount(cs); complete {
+		fmt.Fprintf(w, "\nComplete change set: %s.\n", count)
+	} else {
+		fmt.Fprintf(w, "\n> **Warning:** incomplete change set: showing the first %s only.\n", count)
+	}
+
+	for _, change := range changes {
+		fmt.Fprintf(w, "\n<details>\n<summary>%s <code>%s</code> (<code>%s</code>)</summary>\n\n",
+			resourceAction(change),
+			markdownHTML(str(change.LogicalResourceId, "?")),
+			markdownHTML(str(change.ResourceType, "?")))
+
+		if change.PhysicalResourceId != nil {
+			fmt.Fprintf(w, "- Resource: %s\n", markdownCode(*change.PhysicalResourceId))
+		}
+
+		for _, detail := range change.Details {
+			d := newChangeDetail(detail)
+
+			var notes []string
+			if d.Source != "" {
+				notes = append(notes, d.Source)
+			}
+
+			if note := d.replacementNote(); note != "" {
+				notes = append(notes, "**"+note+"**")
+			}
+
+			fmt.Fprintf(w, "- Change: %s %s", markdownCode(d.Target), markdownCode(d.Cause))
+			if len(notes) > 0 {
+				fmt.Fprintf(w, " (%s)", strings.Join(notes, ", "))
+			}
+
+			fmt.Fprintf(w, "\n")
+		}
+
+		if change.PhysicalResourceId == nil && len(change.Details) == 0 {
+			fmt.Fprintf(w, "No details.\n")
+		}
+
+		fmt.Fprintf(w, "\n</details>\n")
+	}
+}
+
+// markdownCode formats s as inline code.
+func markdownCode(s string) string {
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+
+	return "`" + s + "`"
+}
+
+// markdownHTML escapes s for use within HTML elements.
+func markdownHTML(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
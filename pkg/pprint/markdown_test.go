@@ -0,0 +1,101 @@
+package pprint
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	cf "github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestChangeSetMarkdown(t *testing.T) {
+	w := &strings.Builder{}
+
+	ChangeSetMarkdown(w, &cf.DescribeChangeSetOutput{
+		StackName:     aws.String("mystack"),
+		ChangeSetName: aws.String("StackUpdate-1"),
+		Status:        aws.String(cf.ChangeSetStatusCreateComplete),
+		Changes: []*cf.Change{
+			{
+				Type: aws.String("Resource"),
+				ResourceChange: &cf.ResourceChange{
+					ResourceType:      aws.String("AWS::SQS::Queue"),
+					Action:            aws.String(cf.ChangeActionAdd),
+					LogicalResourceId: aws.String("NewQueue"),
+				},
+			},
+			{
+				Type: aws.String("Resource"),
+				ResourceChange: &cf.ResourceChange{
+					Replacement:        aws.String("Conditional"),
+					ResourceType:       aws.String("AWS::SQS::Queue"),
+					Action:             aws.String(cf.ChangeActionModify),
+					LogicalResourceId:  aws.String("Queue"),
+					PhysicalResourceId: aws.String("https://sqs/queue"),
+					Details: []*cf.ResourceChangeDetail{
+						{
+							CausingEntity: aws.String("Size"),
+							Evaluation:    aws.String(cf.EvaluationTypeStatic),
+							ChangeSource:  aws.String(cf.ChangeSourceParameterReference),
+							Target: &cf.ResourceTargetDefinition{
+								RequiresRecreation: aws.String(cf.RequiresRecreationConditionally),
+								Attribute:          aws.String("Properties"),
+								Name:               aws.String("VisibilityTimeout"),
+							},
+						},
+					},
+				},
+			},
+			{
+				Type: aws.String("Resource"),
+				ResourceChange: &cf.ResourceChange{
+					Replacement:        aws.String("True"),
+					ResourceType:       aws.String("AWS::SNS::Topic"),
+					Action:             aws.String(cf.ChangeActionModify),
+					LogicalResourceId:  aws.String("Topic"),
+					PhysicalResourceId: aws.String("arn:topic"),
+				},
+			},
+		},
+	})
+
+	require.Equal(t, "### Stack `mystack`: change set `StackUpdate-1`\n"+`
+| Resource type | Add | Modify | Remove | Replace |
+|---|--:|--:|--:|--:|
+| `+"`AWS::SNS::Topic`"+` | 0 | 0 | 0 | 1 |
+| `+"`AWS::SQS::Queue`"+` | 1 | 1 | 0 | 0 |
+| **Total** | **1** | **1** | **0** | **1** |
+
+Complete change set: 3 changes.
+
+<details>
+<summary>Add <code>NewQueue</code> (<code>AWS::SQS::Queue</code>)</summary>
+
+No details.
+
+</details>
+
+<details>
+<summary>Modify <code>Queue</code> (<code>AWS::SQS::Queue</code>)</summary>
+
+- Resource: `+"`https://sqs/queue`"+`
+- Change: `+"`Properties.VisibilityTimeout` `<- !Ref Size`"+` (**conditional replacement**)
+
+</details>
+
+<details>
+<summary>Replace <code>Topic</code> (<code>AWS::SNS::Topic</code>)</summary>
+
+- Resource: `+"`arn:topic`"+`
+
+</details>
+`, w.String())
+
+	w.Reset()
+	ChangeSetMarkdown(w, &cf.DescribeChangeSetOutput{
+		StackName:     aws.String("mystack"),
+		ChangeSetName: aws.String("StackUpdate-2"),
+		Status:        aws.String(cf.ChangeSetStatusCreateComplete),
+	})
+	require.Equal(t, "### Stack `mystack`: change set `StackUpdate-2`\n\nOnly outputs have changed.\n", w.String())
+}
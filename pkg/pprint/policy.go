@@ -0,0 +1,49 @@
+package pprint
+
+import (
+	"fmt"
+	"github.com/tetratom/cftool/pkg/policy"
+	"io"
+	"strings"
+)
+
+// PolicyFindings prints the verdict of the policy on each resource change
+// that a rule matched.
+func PolicyFindings(w io.Writer, findings []*policy.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n")
+	ColField.Fprintf(w, "Policy:\n")
+
+	for _, finding := range findings {
+		col := ColWarning
+		if finding.Verdict == policy.Deny {
+			col = ColError
+		}
+
+		col.Fprintf(w, "  %s", finding.Verdict)
+		fmt.Fprintf(w, " %s", finding.ResourceType)
+		ColLogicalId.Fprintf(w, " %s", finding.LogicalId)
+		fmt.Fprintf(w, " (%s", finding.Action)
+		if finding.Replacement != "" {
+			fmt.Fprintf(w, ", replacement: %s", finding.Replacement)
+		}
+
+		fmt.Fprintf(w, ")")
+
+		var reasons []string
+		for _, rule := range finding.Rules {
+			if rule.Reason != "" {
+				reasons = append(reasons, rule.Reason)
+			}
+		}
+
+		if len(reasons) > 0 {
+			fmt.Fprintf(w, ": %s", strings.Join(reasons, "; "))
+		}
+
+		fmt.Fprintf(w, "\n")
+	}
+}
@@ -0,0 +1,41 @@
+package pprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/policy"
+	"strings"
+	"testing"
+)
+
+func TestPolicyFindings(t *testing.T) {
+	w := &strings.Builder{}
+
+	PolicyFindings(w, nil)
+	require.Equal(t, "", w.String())
+
+	PolicyFindings(w, []*policy.Finding{
+		{
+			LogicalId:    "Database",
+			ResourceType: "AWS::RDS::DBInstance",
+			Action:       "Modify",
+			Replacement:  "True",
+			Verdict:      policy.Deny,
+			Rules: []*policy.Rule{
+				{Reason: "Databases must not be replaced."},
+				{},
+			},
+		},
+		{
+			LogicalId:    "LogBucket",
+			ResourceType: "AWS::S3::Bucket",
+			Action:       "Remove",
+			Verdict:      policy.Warn,
+		},
+	})
+
+	require.Equal(t, `
+Policy:
+  deny AWS::RDS::DBInstance Database (Modify, replacement: True): Databases must not be replaced.
+  warn AWS::S3::Bucket LogBucket (Remove)
+`, w.String())
+}
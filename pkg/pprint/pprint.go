@@ -1,6 +1,7 @@
 package pprint
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/fatih/color"
 	"io"
@@ -45,13 +46,21 @@ func DisableColor() {
 	}
 }
 
-func Promptf(w io.Writer, text string, args ...interface{}) bool {
+// Promptf asks a yes/no question, reading the answer from in. If in is
+// closed, the answer is no.
+func Promptf(w io.Writer, in *bufio.Reader, text string, args ...interface{}) bool {
 	for {
 		_, _ = fmt.Fprintf(w, text+" [y/n] ", args...)
-		var input string
-		_, _ = fmt.Scan(&input)
+		line, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			_, _ = fmt.Fprintf(w, "\n")
+			return false
+		}
+
+		switch input := strings.TrimSpace(line); input {
+		case "":
+			continue
 
-		switch input {
 		case "y":
 			return true
 
@@ -1,6 +1,7 @@
 package pprint
 
 import (
+	"bufio"
 	cf "github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/stretchr/testify/require"
 	"strings"
@@ -33,3 +34,13 @@ func TestSmoke(t *testing.T) {
 		})
 	}
 }
+
+func TestPromptf(t *testing.T) {
+	w := &strings.Builder{}
+	in := bufio.NewReader(strings.NewReader("maybe\n\ny\nn"))
+
+	require.True(t, Promptf(w, in, "Continue?"))
+	require.Equal(t, "Continue? [y/n] Please answer y or n.\nContinue? [y/n] Continue? [y/n] ", w.String())
+	require.False(t, Promptf(w, in, "Continue?"))
+	require.False(t, Promptf(w, in, "Continue?"))
+}
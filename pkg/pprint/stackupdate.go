@@ -2,8 +2,10 @@ package pprint
 
 import (
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
 	cf "github.com/aws/aws-sdk-go/service/cloudformation"
 	"io"
+	"strings"
 )
 
 func str(s *string, def string) string {
@@ -37,12 +39,27 @@ func ChangeHeader(w io.Writer, action string, resourceType string, logicalResour
 	fmt.Fprintf(w, "\n")
 }
 
+// changeCount describes the number of changes in a change set, and whether
+// all of them were retrieved.
+func changeCount(cs *cf.DescribeChangeSetOutput) (string, bool) {
+	noun := "changes"
+	if len(cs.Changes) == 1 {
+		noun = "change"
+	}
+
+	count := fmt.Sprintf("%d %s", len(cs.Changes), noun)
+	return count, cs.NextToken == nil
+}
+
+// ChangeSet prints a summary of every change, followed by whether the
+// change set is complete. It is not if cs is a single page of a longer
+// change set.
 func ChangeSet(w io.Writer, cs *cf.DescribeChangeSetOutput) {
 	if len(cs.Changes) == 0 {
 		if *cs.Status != cf.ChangeSetStatusFailed {
-			fmt.Printf("\nOnly outputs have changed.\n")
+			fmt.Fprintf(w, "\nOnly outputs have changed.\n")
 		} else {
-			fmt.Printf("\nNo changes.\n")
+			fmt.Fprintf(w, "\nNo changes.\n")
 		}
 
 		return
@@ -88,87 +105,148 @@ func ChangeSet(w io.Writer, cs *cf.DescribeChangeSetOutput) {
 			ChangeSetDetail(w, detail)
 		}
 	}
+
+	fmt.Fprintf(w, "\n")
+	if count, complete := changeCount(cs); complete {
+		fmt.Fprintf(w, "Complete change set: %s.\n", count)
+	} else {
+		Warningf(w, "Incomplete change set: showing the first %s only.", count)
+	}
 }
 
-func ChangeSetDetail(w io.Writer, detail *cf.ResourceChangeDetail) {
+// changeDetail is a resource change detail as it is shown to the user: the
+// changed target, its cause (e.g. "<- !Ref Foo"), and notes on how and
+// whether the resource is replaced.
+type changeDetail struct {
+	Target             string
+	Cause              string
+	Source             string
+	RequiresRecreation string
+}
+
+func newChangeDetail(detail *cf.ResourceChangeDetail) changeDetail {
 	changeSource := str(detail.ChangeSource, "")
 	targetAttribute := str(detail.Target.Attribute, "")
 	targetPropertyName := str(detail.Target.Name, "")
-	targetRequiresRecreation := str(detail.Target.RequiresRecreation, "")
 	evaluation := str(detail.Evaluation, "")
 	causingEntity := str(detail.CausingEntity, "")
 
-	BeginField(w, "   Change")
+	result := changeDetail{
+		Target:             targetAttribute,
+		RequiresRecreation: str(detail.Target.RequiresRecreation, ""),
+	}
 
-	fmt.Fprintf(w, targetAttribute)
 	if targetPropertyName != "" {
-		fmt.Fprintf(w, ".%s", targetPropertyName)
+		result.Target += "." + targetPropertyName
 	}
 
 	if evaluation == cf.EvaluationTypeDynamic {
-		fmt.Fprintf(w, " <~")
+		result.Cause = "<~"
 	} else {
-		fmt.Fprintf(w, " <-")
+		result.Cause = "<-"
 	}
 
 	switch changeSource {
 	case cf.ChangeSourceResourceReference, cf.ChangeSourceParameterReference:
-		fmt.Fprintf(w, " !Ref")
+		result.Cause += " !Ref"
 		if causingEntity != "" {
-			fmt.Fprintf(w, " %s", causingEntity)
+			result.Cause += " " + causingEntity
 		}
 	case cf.ChangeSourceResourceAttribute:
-		fmt.Fprintf(w, " !GetAtt")
+		result.Cause += " !GetAtt"
 		if causingEntity != "" {
-			fmt.Fprintf(w, " %s", causingEntity)
+			result.Cause += " " + causingEntity
 		}
 	case cf.ChangeSourceDirectModification, cf.ChangeSourceAutomatic:
-		fmt.Fprintf(w, " ...")
+		result.Cause += " ..."
 	default:
-		fmt.Fprintf(w, " ??? unknown change source \"%s\"", changeSource)
-
+		result.Cause += fmt.Sprintf(" ??? unknown change source \"%s\"", changeSource)
 	}
 
-	commentCount := 0
 	switch changeSource {
 	case cf.ChangeSourceDirectModification:
-		fmt.Fprintf(w, " (direct modification")
-		commentCount += 1
-
+		result.Source = "direct modification"
 	case cf.ChangeSourceAutomatic:
-		fmt.Fprintf(w, " (automatic")
-		commentCount += 1
+		result.Source = "automatic"
 	}
 
-	switch targetRequiresRecreation {
+	return result
+}
+
+// replacementNote describes RequiresRecreation, or is empty if the resource
+// is not replaced.
+func (d changeDetail) replacementNote() string {
+	switch d.RequiresRecreation {
 	case cf.RequiresRecreationConditionally:
-		if commentCount == 0 {
-			fmt.Fprintf(w, " (")
-		} else {
-			fmt.Fprintf(w, ", ")
-		}
+		return "conditional replacement"
+	case cf.RequiresRecreationAlways:
+		return "always replace"
+	default:
+		return ""
+	}
+}
 
-		ColWarning.Fprintf(w, "conditional replacement")
-		commentCount += 1
+func ChangeSetDetail(w io.Writer, detail *cf.ResourceChangeDetail) {
+	d := newChangeDetail(detail)
 
-	case cf.RequiresRecreationAlways:
-		if commentCount == 0 {
+	BeginField(w, "   Change")
+	fmt.Fprintf(w, "%s %s", d.Target, d.Cause)
+
+	notes := 0
+	if d.Source != "" {
+		fmt.Fprintf(w, " (%s", d.Source)
+		notes++
+	}
+
+	if note := d.replacementNote(); note != "" {
+		if notes == 0 {
 			fmt.Fprintf(w, " (")
 		} else {
 			fmt.Fprintf(w, ", ")
 		}
 
-		ColError.Fprintf(w, "always replace")
-		commentCount += 1
+		col := ColWarning
+		if d.RequiresRecreation == cf.RequiresRecreationAlways {
+			col = ColError
+		}
+
+		col.Fprintf(w, "%s", note)
+		notes++
 	}
 
-	if commentCount > 0 {
+	if notes > 0 {
 		fmt.Fprintf(w, ")")
 	}
 
 	fmt.Fprintf(w, "\n")
 }
 
+// ResourceChange prints everything the change set says about a single
+// resource.
+func ResourceChange(w io.Writer, change *cf.ResourceChange) {
+	ChangeHeader(
+		w,
+		str(change.Action, ""),
+		str(change.ResourceType, "?"),
+		str(change.LogicalResourceId, "?"))
+
+	if change.PhysicalResourceId != nil {
+		Field(w, " Resource", *change.PhysicalResourceId)
+	}
+
+	if change.Replacement != nil {
+		Field(w, " Replace", *change.Replacement)
+	}
+
+	if len(change.Scope) > 0 {
+		Field(w, " Scope", strings.Join(aws.StringValueSlice(change.Scope), ", "))
+	}
+
+	for _, detail := range change.Details {
+		ChangeSetDetail(w, detail)
+	}
+}
+
 func StackEvent(w io.Writer, event *cf.StackEvent) {
 	ColError.Fprintf(w, "Error! %s", *event.ResourceType)
 	ColLogicalId.Fprintf(w, " %s", *event.LogicalResourceId)
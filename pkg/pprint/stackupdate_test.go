@@ -69,6 +69,8 @@ func TestPPrintChangeSet(t *testing.T) {
 - AWS::ReplacedResource MyResource
 + AWS::ReplacedResource MyResource
   Resource: PhysicalId
+
+Complete change set: 3 changes.
 `,
 		},
 	}
@@ -81,3 +83,27 @@ func TestPPrintChangeSet(t *testing.T) {
 		})
 	}
 }
+
+func TestPPrintChangeSet_Incomplete(t *testing.T) {
+	w := &strings.Builder{}
+
+	ChangeSet(w, &cf.DescribeChangeSetOutput{
+		NextToken: aws.String("token"),
+		Changes: []*cf.Change{
+			{
+				Type: aws.String("Resource"),
+				ResourceChange: &cf.ResourceChange{
+					ResourceType:      aws.String("AWS::Resource"),
+					Action:            aws.String(cf.ChangeActionAdd),
+					LogicalResourceId: aws.String("MyResource"),
+				},
+			},
+		},
+	})
+
+	require.Equal(t, `
++ AWS::Resource MyResource
+
+WARNING! Incomplete change set: showing the first 1 change only.
+`, w.String())
+}
@@ -0,0 +1,136 @@
+package pprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"io"
+)
+
+func changeSymbol(kind cftemplate.ChangeKind) (string, *color.Color) {
+	switch kind {
+	case cftemplate.ChangeAdd:
+		return "+", ColAdd
+	case cftemplate.ChangeRemove:
+		return "-", ColRemove
+	default:
+		return "~", ColModify
+	}
+}
+
+// TemplateDiff prints the changes between two templates, grouped by section
+// and by logical ID.
+func TemplateDiff(w io.Writer, changes []cftemplate.Change) {
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "No template changes.\n")
+		return
+	}
+
+	var section, logicalId string
+
+	for _, change := range changes {
+		if change.Section != section {
+			section, logicalId = change.Section, ""
+			ColDiffHeader.Fprintf(w, "%s\n", section)
+		}
+
+		symbol, col := changeSymbol(change.Kind)
+
+		switch {
+		case change.LogicalId == "":
+			col.Fprintf(w, "  %s ", symbol)
+			if change.Path != "" {
+				fmt.Fprintf(w, "%s: ", change.Path)
+			}
+
+			fmt.Fprintf(w, "%s\n", changeValue(change))
+
+		case change.Path == "":
+			logicalId = change.LogicalId
+			col.Fprintf(w, "  %s", symbol)
+			ColLogicalId.Fprintf(w, " %s", change.LogicalId)
+
+			if change.Kind == cftemplate.ChangeModify {
+				fmt.Fprintf(w, ": %s", changeValue(change))
+			} else if typ := resourceType(change); typ != "" {
+				fmt.Fprintf(w, " (%s)", typ)
+			}
+
+			fmt.Fprintf(w, "\n")
+
+		default:
+			if change.LogicalId != logicalId {
+				logicalId = change.LogicalId
+				ColModify.Fprintf(w, "  ~")
+				ColLogicalId.Fprintf(w, " %s\n", logicalId)
+			}
+
+			col.Fprintf(w, "      %s ", symbol)
+			fmt.Fprintf(w, "%s: %s\n", change.Path, changeValue(change))
+		}
+	}
+}
+
+func changeValue(change cftemplate.Change) string {
+	switch change.Kind {
+	case cftemplate.ChangeAdd:
+		return compact(change.New)
+	case cftemplate.ChangeRemove:
+		return compact(change.Old)
+	default:
+		return compact(change.Old) + " => " + compact(change.New)
+	}
+}
+
+// resourceType returns the type of an added or removed resource.
+func resourceType(change cftemplate.Change) string {
+	if change.Section != "Resources" {
+		return ""
+	}
+
+	value := change.New
+	if change.Kind == cftemplate.ChangeRemove {
+		value = change.Old
+	}
+
+	m, _ := value.(map[string]interface{})
+	typ, _ := m["Type"].(string)
+	return typ
+}
+
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
+
+// ValueDiff prints the changes to a stack's parameters or tags under the
+// given title. Nothing is printed if there are no changes.
+func ValueDiff(w io.Writer, title string, changes []cftool.ValueChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	ColDiffHeader.Fprintf(w, "%s\n", title)
+
+	for _, change := range changes {
+		switch change.Kind {
+		case cftemplate.ChangeAdd:
+			ColDiffAdd.Fprintf(w, "  + %s: %s", change.Key, change.New)
+		case cftemplate.ChangeRemove:
+			ColDiffRemove.Fprintf(w, "  - %s: %s", change.Key, change.Old)
+		default:
+			ColModify.Fprintf(w, "  ~ %s: ", change.Key)
+			ColDiffRemove.Fprintf(w, "%s", change.Old)
+			fmt.Fprintf(w, " => ")
+			ColDiffAdd.Fprintf(w, "%s", change.New)
+		}
+
+		fmt.Fprintf(w, "\n")
+	}
+}
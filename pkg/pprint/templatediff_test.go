@@ -0,0 +1,78 @@
+package pprint
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/tetratom/cftool/pkg/cftemplate"
+	"github.com/tetratom/cftool/pkg/cftool"
+	"strings"
+	"testing"
+)
+
+func TestTemplateDiff(t *testing.T) {
+	w := &strings.Builder{}
+
+	TemplateDiff(w, nil)
+	require.Equal(t, "No template changes.\n", w.String())
+
+	w.Reset()
+	TemplateDiff(w, []cftemplate.Change{
+		{Kind: cftemplate.ChangeAdd, Section: "Description", New: "Changed."},
+		{
+			Kind:      cftemplate.ChangeRemove,
+			Section:   "Parameters",
+			LogicalId: "Password",
+			Old:       map[string]interface{}{"Type": "String"},
+		},
+		{
+			Kind:      cftemplate.ChangeAdd,
+			Section:   "Resources",
+			LogicalId: "Bucket",
+			New:       map[string]interface{}{"Type": "AWS::S3::Bucket"},
+		},
+		{
+			Kind:      cftemplate.ChangeModify,
+			Section:   "Resources",
+			LogicalId: "Queue",
+			Path:      "Properties.QueueName",
+			Old:       map[string]interface{}{"Fn::Sub": "a"},
+			New:       map[string]interface{}{"Fn::Sub": "b"},
+		},
+		{
+			Kind:      cftemplate.ChangeAdd,
+			Section:   "Resources",
+			LogicalId: "Queue",
+			Path:      "Properties.VisibilityTimeout",
+			New:       "60",
+		},
+	})
+
+	require.Equal(t, `Description
+  + "Changed."
+Parameters
+  - Password
+Resources
+  + Bucket (AWS::S3::Bucket)
+  ~ Queue
+      ~ Properties.QueueName: {"Fn::Sub":"a"} => {"Fn::Sub":"b"}
+      + Properties.VisibilityTimeout: "60"
+`, w.String())
+}
+
+func TestValueDiff(t *testing.T) {
+	w := &strings.Builder{}
+
+	ValueDiff(w, "Stack tags", nil)
+	require.Equal(t, "", w.String())
+
+	ValueDiff(w, "Stack tags", []cftool.ValueChange{
+		{Kind: cftemplate.ChangeAdd, Key: "Added", New: "d"},
+		{Kind: cftemplate.ChangeModify, Key: "Changed", Old: "b", New: "B"},
+		{Kind: cftemplate.ChangeRemove, Key: "Removed", Old: "c"},
+	})
+
+	require.Equal(t, `Stack tags
+  + Added: d
+  ~ Changed: b => B
+  - Removed: c
+`, w.String())
+}